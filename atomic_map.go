@@ -0,0 +1,235 @@
+package radixtree
+
+import (
+	"iter"
+	"sync"
+	"sync/atomic"
+)
+
+// AtomicMap is a Map which is safe for concurrent use, and whose readers
+// never lock. Every write copies the nodes it changes, sharing all the others
+// with the previous version of the tree, and then publishes the new version
+// atomically, so readers always see a consistent tree, even in the callbacks
+// of the traversals, which may use the map themselves.
+// Writers are serialized, so the callbacks of Update and Upsert must not
+// write to the map.
+type AtomicMap[V any] struct {
+	mutex   sync.Mutex
+	current atomic.Pointer[Map[V]]
+}
+
+func (a *AtomicMap[V]) load() *Map[V] {
+	current := a.current.Load()
+	if current == nil {
+		return &Map[V]{}
+	}
+	return current
+}
+
+// write applies change to a copy of the current version, which is published
+// as the new one afterwards
+func (a *AtomicMap[V]) write(change func(next *Map[V])) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	next := *a.load()
+	next.gen = newGeneration()
+	change(&next)
+	a.current.Store(&next)
+}
+
+func (a *AtomicMap[V]) Add(str string, data V) {
+	a.write(func(next *Map[V]) {
+		next.Add(str, data)
+	})
+}
+
+func (a *AtomicMap[V]) Put(str string, data V) (old V, replaced bool) {
+	a.write(func(next *Map[V]) {
+		old, replaced = next.Put(str, data)
+	})
+	return old, replaced
+}
+
+func (a *AtomicMap[V]) Remove(str string) {
+	a.write(func(next *Map[V]) {
+		next.Remove(str)
+	})
+}
+
+func (a *AtomicMap[V]) RemovePrefix(prefix string) (removed int64) {
+	a.write(func(next *Map[V]) {
+		removed = next.RemovePrefix(prefix)
+	})
+	return removed
+}
+
+func (a *AtomicMap[V]) GetOrAdd(str string, data V) (actual V, loaded bool) {
+	a.write(func(next *Map[V]) {
+		actual, loaded = next.GetOrAdd(str, data)
+	})
+	return actual, loaded
+}
+
+func (a *AtomicMap[V]) Update(str string, update func(old V, exists bool) (V, bool)) (data V, kept bool) {
+	a.write(func(next *Map[V]) {
+		data, kept = next.Update(str, update)
+	})
+	return data, kept
+}
+
+func (a *AtomicMap[V]) Upsert(str string, upsert func(old V, exists bool) V) (data V) {
+	a.write(func(next *Map[V]) {
+		data = next.Upsert(str, upsert)
+	})
+	return data
+}
+
+func (a *AtomicMap[V]) CompareAndSwap(str string, old, data V) (swapped bool) {
+	a.write(func(next *Map[V]) {
+		swapped = next.CompareAndSwap(str, old, data)
+	})
+	return swapped
+}
+
+func (a *AtomicMap[V]) Swap(str string, data V) (previous V, loaded bool) {
+	a.write(func(next *Map[V]) {
+		previous, loaded = next.Swap(str, data)
+	})
+	return previous, loaded
+}
+
+func (a *AtomicMap[V]) LoadAndDelete(str string) (data V, loaded bool) {
+	a.write(func(next *Map[V]) {
+		data, loaded = next.LoadAndDelete(str)
+	})
+	return data, loaded
+}
+
+func (a *AtomicMap[V]) Get(str string) (V, bool) {
+	return a.load().Get(str)
+}
+
+func (a *AtomicMap[V]) Size() int64 {
+	return a.load().Size()
+}
+
+func (a *AtomicMap[V]) CountWithPrefix(prefix string) int64 {
+	return a.load().CountWithPrefix(prefix)
+}
+
+func (a *AtomicMap[V]) CountInRange(from, to string, opts ...RangeOption) int64 {
+	return a.load().CountInRange(from, to, opts...)
+}
+
+func (a *AtomicMap[V]) Min() (string, V, bool) {
+	return a.load().Min()
+}
+
+func (a *AtomicMap[V]) Max() (string, V, bool) {
+	return a.load().Max()
+}
+
+func (a *AtomicMap[V]) Floor(str string) (string, V, bool) {
+	return a.load().Floor(str)
+}
+
+func (a *AtomicMap[V]) Ceiling(str string) (string, V, bool) {
+	return a.load().Ceiling(str)
+}
+
+func (a *AtomicMap[V]) Predecessor(str string) (string, V, bool) {
+	return a.load().Predecessor(str)
+}
+
+func (a *AtomicMap[V]) Successor(str string) (string, V, bool) {
+	return a.load().Successor(str)
+}
+
+func (a *AtomicMap[V]) LongestPrefix(str string) (string, V, bool) {
+	return a.load().LongestPrefix(str)
+}
+
+func (a *AtomicMap[V]) Rank(str string) int64 {
+	return a.load().Rank(str)
+}
+
+func (a *AtomicMap[V]) Select(index int64) (string, V, bool) {
+	return a.load().Select(index)
+}
+
+func (a *AtomicMap[V]) ForEach(action func(string, V)) {
+	a.load().ForEach(action)
+}
+
+func (a *AtomicMap[V]) ForEachWithPrefix(prefix string, action func(string, V)) {
+	a.load().ForEachWithPrefix(prefix, action)
+}
+
+func (a *AtomicMap[V]) ForEachReverse(action func(string, V)) {
+	a.load().ForEachReverse(action)
+}
+
+func (a *AtomicMap[V]) ForEachWithPrefixReverse(prefix string, action func(string, V)) {
+	a.load().ForEachWithPrefixReverse(prefix, action)
+}
+
+func (a *AtomicMap[V]) ForEachInRange(from, to string, action func(string, V), opts ...RangeOption) {
+	a.load().ForEachInRange(from, to, action, opts...)
+}
+
+func (a *AtomicMap[V]) ForEachPrefixOf(str string, action func(string, V)) {
+	a.load().ForEachPrefixOf(str, action)
+}
+
+func (a *AtomicMap[V]) Walk(action func(string, V) WalkControl) {
+	a.load().Walk(action)
+}
+
+func (a *AtomicMap[V]) WalkWithPrefix(prefix string, action func(string, V) WalkControl) {
+	a.load().WalkWithPrefix(prefix, action)
+}
+
+// the iterators load the current version when the loop starts
+
+func (a *AtomicMap[V]) All() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		a.load().All()(yield)
+	}
+}
+
+func (a *AtomicMap[V]) Keys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		a.load().Keys()(yield)
+	}
+}
+
+func (a *AtomicMap[V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		a.load().Values()(yield)
+	}
+}
+
+func (a *AtomicMap[V]) WithPrefix(prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		a.load().WithPrefix(prefix)(yield)
+	}
+}
+
+func (a *AtomicMap[V]) Range(from, to string, opts ...RangeOption) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		a.load().Range(from, to, opts...)(yield)
+	}
+}
+
+func (a *AtomicMap[V]) Backward() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		a.load().Backward()(yield)
+	}
+}
+
+// Iterator returns an iterator over the current version of the map,
+// which is not affected by later changes to it.
+func (a *AtomicMap[V]) Iterator() *Iterator[V] {
+	return a.load().Iterator()
+}
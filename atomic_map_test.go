@@ -0,0 +1,203 @@
+package radixtree_test
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtomicMap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("behaves like a map", func(t *testing.T) {
+		amap := radixtree.AtomicMap[int]{}
+
+		assert.EqualValues(t, 0, amap.Size())
+		_, exists := amap.Get("bliss")
+		assert.False(t, exists)
+
+		amap.Add("bliss", 1)
+		amap.Add("blissful", 2)
+		amap.Add("blister", 3)
+
+		_, replaced := amap.Put("blissful", 4)
+		assert.True(t, replaced)
+
+		data, exists := amap.Get("blissful")
+		assert.True(t, exists)
+		assert.EqualValues(t, 4, data)
+
+		assert.EqualValues(t, 3, amap.Size())
+		assert.EqualValues(t, 2, amap.CountWithPrefix("bliss"))
+		assert.EqualValues(t, 1, amap.Rank("blissful"))
+
+		key, _, found := amap.LongestPrefix("blissfulness")
+		assert.True(t, found)
+		assert.Equal(t, "blissful", key)
+
+		assert.True(t, amap.CompareAndSwap("blister", 3, 5))
+		data, loaded := amap.LoadAndDelete("blister")
+		assert.True(t, loaded)
+		assert.EqualValues(t, 5, data)
+
+		amap.Remove("bliss")
+		_, exists = amap.Get("bliss")
+		assert.False(t, exists)
+		assert.EqualValues(t, 1, amap.Size())
+		assert.Equal(t, []string{"blissful"}, slices.Collect(amap.Keys()))
+	})
+
+	t.Run("callbacks can use the map", func(t *testing.T) {
+		amap := radixtree.AtomicMap[int]{}
+
+		amap.Add("a", 1)
+		amap.Add("b", 2)
+
+		amap.ForEach(func(s string, data int) {
+			amap.Add(s+s, data*10)
+		})
+
+		for key, data := range amap.WithPrefix("a") {
+			if key == "a" {
+				amap.Remove("b")
+			}
+			amap.Put(key, data+1)
+		}
+
+		assert.Equal(t, []string{"a", "aa", "bb"}, slices.Collect(amap.Keys()))
+		assert.Equal(t, []int{2, 11, 20}, slices.Collect(amap.Values()))
+	})
+
+	t.Run("old versions are never changed", func(t *testing.T) {
+		amap := radixtree.AtomicMap[int]{}
+		model := map[string]int{}
+
+		random := rand.New(rand.NewSource(42))
+		iterators := []*radixtree.Iterator[int]{}
+		expected := [][]string{}
+
+		for i := 0; i < 500; i++ {
+			key := strconv.FormatInt(int64(random.Intn(300)), 3)
+			switch random.Intn(4) {
+			case 0:
+				amap.Remove(key)
+				delete(model, key)
+			case 1:
+				amap.RemovePrefix(key)
+				for k := range model {
+					if len(k) >= len(key) && k[:len(key)] == key {
+						delete(model, k)
+					}
+				}
+			default:
+				amap.Put(key, i)
+				model[key] = i
+			}
+
+			entries := []string{}
+			for k, v := range model {
+				entries = append(entries, fmt.Sprintf("%s=%d", k, v))
+			}
+			slices.Sort(entries)
+
+			iterators = append(iterators, amap.Iterator())
+			expected = append(expected, entries)
+		}
+
+		for i, it := range iterators {
+			entries := []string{}
+			for ok := it.First(); ok; ok = it.Next() {
+				entries = append(entries, fmt.Sprintf("%s=%d", it.Key(), it.Value()))
+			}
+			slices.Sort(entries)
+			assert.Equal(t, expected[i], entries)
+		}
+	})
+
+	t.Run("readers see a consistent tree", func(t *testing.T) {
+		amap := radixtree.AtomicMap[int]{}
+
+		const keys, readers = 500, 4
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				amap.Add(strconv.Itoa(i), i)
+			}
+		}()
+
+		for r := 0; r < readers; r++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < keys; i++ {
+					count, highest := 0, -1
+					amap.ForEach(func(_ string, data int) {
+						count++
+						highest = max(highest, data)
+					})
+					// the words are added in order, so each version
+					// holds all of them up to the highest one
+					assert.Equal(t, highest+1, count)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		assert.EqualValues(t, keys, amap.Size())
+	})
+
+	t.Run("concurrent readers and writers", func(t *testing.T) {
+		amap := radixtree.AtomicMap[int]{}
+
+		const writers, readers, keys = 4, 4, 200
+
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < keys; i++ {
+					key := fmt.Sprintf("w%d/%d", w, i)
+					amap.Add(key, i)
+					amap.Upsert("total", func(old int, _ bool) int {
+						return old + 1
+					})
+					if i%2 == 0 {
+						amap.Remove(key)
+					}
+				}
+			}(w)
+		}
+
+		for r := 0; r < readers; r++ {
+			wg.Add(1)
+			go func(r int) {
+				defer wg.Done()
+				for i := 0; i < keys; i++ {
+					amap.Get(fmt.Sprintf("w%d/%d", r, i))
+					amap.CountWithPrefix(fmt.Sprintf("w%d/", r))
+					amap.ForEachWithPrefix(fmt.Sprintf("w%d/1", r), func(_ string, _ int) {})
+					for range amap.Range("w0", "w2") {
+						break
+					}
+				}
+			}(r)
+		}
+
+		wg.Wait()
+
+		total, _ := amap.Get("total")
+		assert.EqualValues(t, writers*keys, total)
+		assert.EqualValues(t, writers*keys/2+1, amap.Size())
+	})
+}
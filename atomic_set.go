@@ -0,0 +1,173 @@
+package radixtree
+
+import (
+	"iter"
+	"sync"
+	"sync/atomic"
+)
+
+// AtomicSet is a Set which is safe for concurrent use, and whose readers
+// never lock. Every write copies the nodes it changes, sharing all the others
+// with the previous version of the tree, and then publishes the new version
+// atomically, so readers always see a consistent tree, even in the callbacks
+// of the traversals, which may use the set themselves.
+type AtomicSet struct {
+	mutex   sync.Mutex
+	current atomic.Pointer[Set]
+}
+
+func (a *AtomicSet) load() *Set {
+	current := a.current.Load()
+	if current == nil {
+		return &Set{}
+	}
+	return current
+}
+
+// write applies change to a copy of the current version, which is published
+// as the new one afterwards
+func (a *AtomicSet) write(change func(next *Set)) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	next := *a.load()
+	next.gen = newGeneration()
+	change(&next)
+	a.current.Store(&next)
+}
+
+func (a *AtomicSet) Add(str string) (added bool) {
+	a.write(func(next *Set) {
+		added = next.Add(str)
+	})
+	return added
+}
+
+func (a *AtomicSet) Remove(str string) {
+	a.write(func(next *Set) {
+		next.Remove(str)
+	})
+}
+
+func (a *AtomicSet) RemovePrefix(prefix string) (removed int64) {
+	a.write(func(next *Set) {
+		removed = next.RemovePrefix(prefix)
+	})
+	return removed
+}
+
+func (a *AtomicSet) Contains(str string) bool {
+	return a.load().Contains(str)
+}
+
+func (a *AtomicSet) Size() int64 {
+	return a.load().Size()
+}
+
+func (a *AtomicSet) CountWithPrefix(prefix string) int64 {
+	return a.load().CountWithPrefix(prefix)
+}
+
+func (a *AtomicSet) CountInRange(from, to string, opts ...RangeOption) int64 {
+	return a.load().CountInRange(from, to, opts...)
+}
+
+func (a *AtomicSet) Min() (string, bool) {
+	return a.load().Min()
+}
+
+func (a *AtomicSet) Max() (string, bool) {
+	return a.load().Max()
+}
+
+func (a *AtomicSet) Floor(str string) (string, bool) {
+	return a.load().Floor(str)
+}
+
+func (a *AtomicSet) Ceiling(str string) (string, bool) {
+	return a.load().Ceiling(str)
+}
+
+func (a *AtomicSet) Predecessor(str string) (string, bool) {
+	return a.load().Predecessor(str)
+}
+
+func (a *AtomicSet) Successor(str string) (string, bool) {
+	return a.load().Successor(str)
+}
+
+func (a *AtomicSet) LongestPrefix(str string) (string, bool) {
+	return a.load().LongestPrefix(str)
+}
+
+func (a *AtomicSet) Rank(str string) int64 {
+	return a.load().Rank(str)
+}
+
+func (a *AtomicSet) Select(index int64) (string, bool) {
+	return a.load().Select(index)
+}
+
+func (a *AtomicSet) ForEach(action func(string)) {
+	a.load().ForEach(action)
+}
+
+func (a *AtomicSet) ForEachWithPrefix(prefix string, action func(string)) {
+	a.load().ForEachWithPrefix(prefix, action)
+}
+
+func (a *AtomicSet) ForEachReverse(action func(string)) {
+	a.load().ForEachReverse(action)
+}
+
+func (a *AtomicSet) ForEachWithPrefixReverse(prefix string, action func(string)) {
+	a.load().ForEachWithPrefixReverse(prefix, action)
+}
+
+func (a *AtomicSet) ForEachInRange(from, to string, action func(string), opts ...RangeOption) {
+	a.load().ForEachInRange(from, to, action, opts...)
+}
+
+func (a *AtomicSet) ForEachPrefixOf(str string, action func(string)) {
+	a.load().ForEachPrefixOf(str, action)
+}
+
+func (a *AtomicSet) Walk(action func(string) WalkControl) {
+	a.load().Walk(action)
+}
+
+func (a *AtomicSet) WalkWithPrefix(prefix string, action func(string) WalkControl) {
+	a.load().WalkWithPrefix(prefix, action)
+}
+
+// the iterators load the current version when the loop starts
+
+func (a *AtomicSet) All() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		a.load().All()(yield)
+	}
+}
+
+func (a *AtomicSet) WithPrefix(prefix string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		a.load().WithPrefix(prefix)(yield)
+	}
+}
+
+func (a *AtomicSet) Range(from, to string, opts ...RangeOption) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		a.load().Range(from, to, opts...)(yield)
+	}
+}
+
+func (a *AtomicSet) Backward() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		a.load().Backward()(yield)
+	}
+}
+
+// Iterator returns an iterator over the current version of the set,
+// which is not affected by later changes to it.
+func (a *AtomicSet) Iterator() *SetIterator {
+	return a.load().Iterator()
+}
@@ -0,0 +1,90 @@
+package radixtree_test
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtomicSet(t *testing.T) {
+	t.Parallel()
+
+	t.Run("behaves like a set", func(t *testing.T) {
+		aset := radixtree.AtomicSet{}
+
+		assert.True(t, aset.Add("bliss"))
+		assert.True(t, aset.Add("blissful"))
+		assert.True(t, aset.Add("blister"))
+		assert.False(t, aset.Add("blister"))
+
+		assert.True(t, aset.Contains("blissful"))
+		assert.EqualValues(t, 3, aset.Size())
+		assert.EqualValues(t, 1, aset.Rank("blissful"))
+
+		word, found := aset.LongestPrefix("blissfulness")
+		assert.True(t, found)
+		assert.Equal(t, "blissful", word)
+
+		assert.EqualValues(t, 2, aset.RemovePrefix("bliss"))
+		assert.EqualValues(t, 1, aset.Size())
+	})
+
+	t.Run("iterator is not affected by later changes", func(t *testing.T) {
+		aset := radixtree.AtomicSet{}
+
+		aset.Add("a")
+		aset.Add("b")
+
+		it := aset.Iterator()
+		aset.Add("ab")
+		aset.Remove("b")
+
+		words := []string{}
+		for ok := it.First(); ok; ok = it.Next() {
+			words = append(words, it.Key())
+		}
+		assert.Equal(t, []string{"a", "b"}, words)
+		assert.Equal(t, []string{"a", "ab"}, slices.Collect(aset.All()))
+	})
+
+	t.Run("concurrent readers and writers", func(t *testing.T) {
+		aset := radixtree.AtomicSet{}
+
+		const writers, readers, words = 4, 4, 200
+
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < words; i++ {
+					word := fmt.Sprintf("w%d/%d", w, i)
+					aset.Add(word)
+					if i%2 == 0 {
+						aset.Remove(word)
+					}
+				}
+			}(w)
+		}
+
+		for r := 0; r < readers; r++ {
+			wg.Add(1)
+			go func(r int) {
+				defer wg.Done()
+				for i := 0; i < words; i++ {
+					aset.Contains(fmt.Sprintf("w%d/%d", r, i))
+					aset.CountWithPrefix(fmt.Sprintf("w%d/", r))
+					aset.ForEachWithPrefix(fmt.Sprintf("w%d/1", r), func(string) {})
+				}
+			}(r)
+		}
+
+		wg.Wait()
+
+		assert.EqualValues(t, writers*words/2, aset.Size())
+	})
+}
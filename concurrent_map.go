@@ -0,0 +1,240 @@
+package radixtree
+
+import (
+	"iter"
+	"sync"
+)
+
+// ConcurrentMap is a Map which is safe for concurrent use. Readers share
+// the map while writers hold it exclusively, and the traversals run their
+// callbacks over a snapshot of the map, without holding any lock, so the
+// callbacks may use the map themselves.
+// The callbacks of Update and Upsert are the exception, since they run as
+// part of the write, so they must not use the map.
+type ConcurrentMap[V any] struct {
+	mutex sync.RWMutex
+	m     Map[V]
+}
+
+// snapshot returns a frozen copy of the map, which the traversals
+// visit without holding the lock
+func (c *ConcurrentMap[V]) snapshot() *Map[V] {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	snapshot := c.m.Snapshot()
+	return &snapshot.m
+}
+
+func (c *ConcurrentMap[V]) Add(str string, data V) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.m.Add(str, data)
+}
+
+func (c *ConcurrentMap[V]) Put(str string, data V) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.m.Put(str, data)
+}
+
+func (c *ConcurrentMap[V]) Remove(str string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.m.Remove(str)
+}
+
+func (c *ConcurrentMap[V]) RemovePrefix(prefix string) int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.m.RemovePrefix(prefix)
+}
+
+func (c *ConcurrentMap[V]) GetOrAdd(str string, data V) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.m.GetOrAdd(str, data)
+}
+
+func (c *ConcurrentMap[V]) Update(str string, update func(old V, exists bool) (V, bool)) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.m.Update(str, update)
+}
+
+func (c *ConcurrentMap[V]) Upsert(str string, upsert func(old V, exists bool) V) V {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.m.Upsert(str, upsert)
+}
+
+func (c *ConcurrentMap[V]) CompareAndSwap(str string, old, data V) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.m.CompareAndSwap(str, old, data)
+}
+
+func (c *ConcurrentMap[V]) Swap(str string, data V) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.m.Swap(str, data)
+}
+
+func (c *ConcurrentMap[V]) LoadAndDelete(str string) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.m.LoadAndDelete(str)
+}
+
+func (c *ConcurrentMap[V]) Get(str string) (V, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.Get(str)
+}
+
+func (c *ConcurrentMap[V]) Size() int64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.Size()
+}
+
+func (c *ConcurrentMap[V]) CountWithPrefix(prefix string) int64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.CountWithPrefix(prefix)
+}
+
+func (c *ConcurrentMap[V]) CountInRange(from, to string, opts ...RangeOption) int64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.CountInRange(from, to, opts...)
+}
+
+func (c *ConcurrentMap[V]) Min() (string, V, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.Min()
+}
+
+func (c *ConcurrentMap[V]) Max() (string, V, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.Max()
+}
+
+func (c *ConcurrentMap[V]) Floor(str string) (string, V, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.Floor(str)
+}
+
+func (c *ConcurrentMap[V]) Ceiling(str string) (string, V, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.Ceiling(str)
+}
+
+func (c *ConcurrentMap[V]) Predecessor(str string) (string, V, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.Predecessor(str)
+}
+
+func (c *ConcurrentMap[V]) Successor(str string) (string, V, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.Successor(str)
+}
+
+func (c *ConcurrentMap[V]) LongestPrefix(str string) (string, V, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.LongestPrefix(str)
+}
+
+func (c *ConcurrentMap[V]) Rank(str string) int64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.Rank(str)
+}
+
+func (c *ConcurrentMap[V]) Select(index int64) (string, V, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.m.Select(index)
+}
+
+func (c *ConcurrentMap[V]) ForEach(action func(string, V)) {
+	c.snapshot().ForEach(action)
+}
+
+func (c *ConcurrentMap[V]) ForEachWithPrefix(prefix string, action func(string, V)) {
+	c.snapshot().ForEachWithPrefix(prefix, action)
+}
+
+func (c *ConcurrentMap[V]) ForEachReverse(action func(string, V)) {
+	c.snapshot().ForEachReverse(action)
+}
+
+func (c *ConcurrentMap[V]) ForEachWithPrefixReverse(prefix string, action func(string, V)) {
+	c.snapshot().ForEachWithPrefixReverse(prefix, action)
+}
+
+func (c *ConcurrentMap[V]) ForEachInRange(from, to string, action func(string, V), opts ...RangeOption) {
+	c.snapshot().ForEachInRange(from, to, action, opts...)
+}
+
+func (c *ConcurrentMap[V]) ForEachPrefixOf(str string, action func(string, V)) {
+	c.snapshot().ForEachPrefixOf(str, action)
+}
+
+func (c *ConcurrentMap[V]) Walk(action func(string, V) WalkControl) {
+	c.snapshot().Walk(action)
+}
+
+func (c *ConcurrentMap[V]) WalkWithPrefix(prefix string, action func(string, V) WalkControl) {
+	c.snapshot().WalkWithPrefix(prefix, action)
+}
+
+// the iterators take their snapshot when the loop starts
+
+func (c *ConcurrentMap[V]) All() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		c.snapshot().All()(yield)
+	}
+}
+
+func (c *ConcurrentMap[V]) Keys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		c.snapshot().Keys()(yield)
+	}
+}
+
+func (c *ConcurrentMap[V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		c.snapshot().Values()(yield)
+	}
+}
+
+func (c *ConcurrentMap[V]) WithPrefix(prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		c.snapshot().WithPrefix(prefix)(yield)
+	}
+}
+
+func (c *ConcurrentMap[V]) Range(from, to string, opts ...RangeOption) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		c.snapshot().Range(from, to, opts...)(yield)
+	}
+}
+
+func (c *ConcurrentMap[V]) Backward() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		c.snapshot().Backward()(yield)
+	}
+}
+
+// Iterator returns an iterator over a snapshot of the map,
+// which is not affected by later changes to it.
+func (c *ConcurrentMap[V]) Iterator() *Iterator[V] {
+	return c.snapshot().Iterator()
+}
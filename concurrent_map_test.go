@@ -0,0 +1,172 @@
+package radixtree_test
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentMap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("behaves like a map", func(t *testing.T) {
+		cmap := radixtree.ConcurrentMap[int]{}
+
+		cmap.Add("bliss", 1)
+		cmap.Add("blissful", 2)
+		cmap.Add("blister", 3)
+
+		_, replaced := cmap.Put("blissful", 4)
+		assert.True(t, replaced)
+
+		data, exists := cmap.Get("blissful")
+		assert.True(t, exists)
+		assert.EqualValues(t, 4, data)
+
+		assert.EqualValues(t, 3, cmap.Size())
+		assert.EqualValues(t, 2, cmap.CountWithPrefix("bliss"))
+		assert.EqualValues(t, 1, cmap.Rank("blissful"))
+
+		key, _, found := cmap.LongestPrefix("blissfulness")
+		assert.True(t, found)
+		assert.Equal(t, "blissful", key)
+
+		cmap.Remove("bliss")
+		_, exists = cmap.Get("bliss")
+		assert.False(t, exists)
+		assert.EqualValues(t, 2, cmap.Size())
+	})
+
+	t.Run("traversals keep the order", func(t *testing.T) {
+		cmap := radixtree.ConcurrentMap[int]{}
+
+		cmap.Add("c", 3)
+		cmap.Add("a", 1)
+		cmap.Add("ab", 2)
+
+		keys := []string{}
+		cmap.ForEach(func(s string, _ int) {
+			keys = append(keys, s)
+		})
+		assert.Equal(t, []string{"a", "ab", "c"}, keys)
+
+		keys = []string{}
+		cmap.ForEachReverse(func(s string, _ int) {
+			keys = append(keys, s)
+		})
+		assert.Equal(t, []string{"c", "ab", "a"}, keys)
+
+		keys = []string{}
+		cmap.Walk(func(s string, _ int) radixtree.WalkControl {
+			keys = append(keys, s)
+			return radixtree.SkipChildren
+		})
+		assert.Equal(t, []string{"a", "c"}, keys)
+
+		assert.Equal(t, []string{"a", "ab", "c"}, slices.Collect(cmap.Keys()))
+		assert.Equal(t, []int{1, 2, 3}, slices.Collect(cmap.Values()))
+	})
+
+	t.Run("callbacks can use the map", func(t *testing.T) {
+		cmap := radixtree.ConcurrentMap[int]{}
+
+		cmap.Add("a", 1)
+		cmap.Add("b", 2)
+
+		cmap.ForEach(func(s string, data int) {
+			cmap.Add(s+s, data*10)
+		})
+
+		for key, data := range cmap.WithPrefix("a") {
+			if key == "a" {
+				cmap.Remove("b")
+			}
+			cmap.Put(key, data+1)
+		}
+
+		assert.Equal(t, []string{"a", "aa", "bb"}, slices.Collect(cmap.Keys()))
+		assert.Equal(t, []int{2, 11, 20}, slices.Collect(cmap.Values()))
+	})
+
+	t.Run("traversals visit the map as it was when they started", func(t *testing.T) {
+		cmap := radixtree.ConcurrentMap[int]{}
+
+		cmap.Add("a", 1)
+		cmap.Add("c", 3)
+
+		keys := []string{}
+		for key := range cmap.All() {
+			keys = append(keys, key)
+			cmap.Add("b", 2)
+			cmap.Remove("c")
+		}
+		assert.Equal(t, []string{"a", "c"}, keys)
+		assert.Equal(t, []string{"a", "b"}, slices.Collect(cmap.Keys()))
+	})
+
+	t.Run("iterator is not affected by later changes", func(t *testing.T) {
+		cmap := radixtree.ConcurrentMap[int]{}
+
+		cmap.Add("a", 1)
+		cmap.Add("b", 2)
+
+		it := cmap.Iterator()
+		cmap.Add("ab", 3)
+		cmap.Remove("b")
+
+		keys := []string{}
+		for ok := it.First(); ok; ok = it.Next() {
+			keys = append(keys, it.Key())
+		}
+		assert.Equal(t, []string{"a", "b"}, keys)
+	})
+
+	t.Run("concurrent readers and writers", func(t *testing.T) {
+		cmap := radixtree.ConcurrentMap[int]{}
+
+		const writers, readers, keys = 4, 4, 200
+
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < keys; i++ {
+					key := fmt.Sprintf("w%d/%d", w, i)
+					cmap.Add(key, i)
+					cmap.Upsert("total", func(old int, _ bool) int {
+						return old + 1
+					})
+					if i%2 == 0 {
+						cmap.Remove(key)
+					}
+				}
+			}(w)
+		}
+
+		for r := 0; r < readers; r++ {
+			wg.Add(1)
+			go func(r int) {
+				defer wg.Done()
+				for i := 0; i < keys; i++ {
+					cmap.Get(fmt.Sprintf("w%d/%d", r, i))
+					cmap.CountWithPrefix(fmt.Sprintf("w%d/", r))
+					cmap.ForEachWithPrefix(fmt.Sprintf("w%d/1", r), func(_ string, _ int) {})
+					for range cmap.Range("w0", "w2") {
+						break
+					}
+				}
+			}(r)
+		}
+
+		wg.Wait()
+
+		total, _ := cmap.Get("total")
+		assert.EqualValues(t, writers*keys, total)
+		assert.EqualValues(t, writers*keys/2+1, cmap.Size())
+	})
+}
@@ -0,0 +1,183 @@
+package radixtree
+
+import (
+	"iter"
+	"sync"
+)
+
+// ConcurrentSet is a Set which is safe for concurrent use. Readers share
+// the set while writers hold it exclusively, and the traversals run their
+// callbacks over a snapshot of the set, without holding any lock, so the
+// callbacks may use the set themselves.
+type ConcurrentSet struct {
+	mutex sync.RWMutex
+	s     Set
+}
+
+// snapshot returns a frozen copy of the set, which the traversals
+// visit without holding the lock
+func (c *ConcurrentSet) snapshot() *Set {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.s.snapshot()
+}
+
+func (c *ConcurrentSet) Add(str string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.s.Add(str)
+}
+
+func (c *ConcurrentSet) Remove(str string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.s.Remove(str)
+}
+
+func (c *ConcurrentSet) RemovePrefix(prefix string) int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.s.RemovePrefix(prefix)
+}
+
+func (c *ConcurrentSet) Contains(str string) bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.Contains(str)
+}
+
+func (c *ConcurrentSet) Size() int64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.Size()
+}
+
+func (c *ConcurrentSet) CountWithPrefix(prefix string) int64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.CountWithPrefix(prefix)
+}
+
+func (c *ConcurrentSet) CountInRange(from, to string, opts ...RangeOption) int64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.CountInRange(from, to, opts...)
+}
+
+func (c *ConcurrentSet) Min() (string, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.Min()
+}
+
+func (c *ConcurrentSet) Max() (string, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.Max()
+}
+
+func (c *ConcurrentSet) Floor(str string) (string, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.Floor(str)
+}
+
+func (c *ConcurrentSet) Ceiling(str string) (string, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.Ceiling(str)
+}
+
+func (c *ConcurrentSet) Predecessor(str string) (string, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.Predecessor(str)
+}
+
+func (c *ConcurrentSet) Successor(str string) (string, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.Successor(str)
+}
+
+func (c *ConcurrentSet) LongestPrefix(str string) (string, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.LongestPrefix(str)
+}
+
+func (c *ConcurrentSet) Rank(str string) int64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.Rank(str)
+}
+
+func (c *ConcurrentSet) Select(index int64) (string, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.s.Select(index)
+}
+
+func (c *ConcurrentSet) ForEach(action func(string)) {
+	c.snapshot().ForEach(action)
+}
+
+func (c *ConcurrentSet) ForEachWithPrefix(prefix string, action func(string)) {
+	c.snapshot().ForEachWithPrefix(prefix, action)
+}
+
+func (c *ConcurrentSet) ForEachReverse(action func(string)) {
+	c.snapshot().ForEachReverse(action)
+}
+
+func (c *ConcurrentSet) ForEachWithPrefixReverse(prefix string, action func(string)) {
+	c.snapshot().ForEachWithPrefixReverse(prefix, action)
+}
+
+func (c *ConcurrentSet) ForEachInRange(from, to string, action func(string), opts ...RangeOption) {
+	c.snapshot().ForEachInRange(from, to, action, opts...)
+}
+
+func (c *ConcurrentSet) ForEachPrefixOf(str string, action func(string)) {
+	c.snapshot().ForEachPrefixOf(str, action)
+}
+
+func (c *ConcurrentSet) Walk(action func(string) WalkControl) {
+	c.snapshot().Walk(action)
+}
+
+func (c *ConcurrentSet) WalkWithPrefix(prefix string, action func(string) WalkControl) {
+	c.snapshot().WalkWithPrefix(prefix, action)
+}
+
+// the iterators take their snapshot when the loop starts
+
+func (c *ConcurrentSet) All() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		c.snapshot().All()(yield)
+	}
+}
+
+func (c *ConcurrentSet) WithPrefix(prefix string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		c.snapshot().WithPrefix(prefix)(yield)
+	}
+}
+
+func (c *ConcurrentSet) Range(from, to string, opts ...RangeOption) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		c.snapshot().Range(from, to, opts...)(yield)
+	}
+}
+
+func (c *ConcurrentSet) Backward() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		c.snapshot().Backward()(yield)
+	}
+}
+
+// Iterator returns an iterator over a snapshot of the set,
+// which is not affected by later changes to it.
+func (c *ConcurrentSet) Iterator() *SetIterator {
+	return c.snapshot().Iterator()
+}
@@ -0,0 +1,77 @@
+package radixtree_test
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentSet(t *testing.T) {
+	t.Parallel()
+
+	t.Run("behaves like a set", func(t *testing.T) {
+		cset := radixtree.ConcurrentSet{}
+
+		assert.True(t, cset.Add("hearing"))
+		assert.True(t, cset.Add("hear"))
+		assert.False(t, cset.Add("hear"))
+		assert.True(t, cset.Add("heartless"))
+
+		assert.True(t, cset.Contains("hear"))
+		assert.EqualValues(t, 3, cset.Size())
+
+		word, found := cset.Successor("hear")
+		assert.True(t, found)
+		assert.Equal(t, "hearing", word)
+
+		assert.EqualValues(t, 2, cset.RemovePrefix("heari")+cset.RemovePrefix("heart"))
+		assert.Equal(t, []string{"hear"}, slices.Collect(cset.All()))
+	})
+
+	t.Run("callbacks can use the set", func(t *testing.T) {
+		cset := radixtree.ConcurrentSet{}
+
+		cset.Add("a")
+		cset.Add("b")
+
+		cset.ForEach(func(s string) {
+			cset.Remove(s)
+			cset.Add(s + "!")
+		})
+
+		assert.Equal(t, []string{"a!", "b!"}, slices.Collect(cset.All()))
+	})
+
+	t.Run("concurrent readers and writers", func(t *testing.T) {
+		cset := radixtree.ConcurrentSet{}
+
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(2)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					cset.Add(fmt.Sprintf("%d/%d", w, i))
+				}
+			}(w)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					cset.Contains(fmt.Sprintf("%d/%d", w, i))
+					cset.Walk(func(_ string) radixtree.WalkControl {
+						return radixtree.Stop
+					})
+					it := cset.Iterator()
+					it.Seek(fmt.Sprintf("%d/", w))
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		assert.EqualValues(t, 400, cset.Size())
+	})
+}
@@ -0,0 +1,695 @@
+package radixtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A durable map is kept in a directory holding a checkpoint, written by
+// Save, and a write-ahead log of the changes made since then. Each record
+// of the log is
+//
+//	payload length (4 bytes) | CRC-32C of the payload (4 bytes) | payload
+//
+// with integers in big endian, where the payload is
+//
+//	operation (byte) | key length (uvarint) | key | value (for puts)
+//
+// A checkpoint first renames the log to wal.1, wal.2 and so on, starting a new
+// one, and then writes a snapshot of the map taken at the same time, deleting
+// the renamed logs once it is written. So only the renaming has to stop the
+// other calls, while the map is written in the background.
+//
+// Opening the map loads the checkpoint and replays the renamed logs left
+// behind and then the log on top of it, stopping at the first incomplete or
+// corrupted record of each, which is what a crash in the middle of a write
+// leaves behind. Replaying is idempotent, as each change overwrites the keys
+// it touches, so a crash between writing a checkpoint and deleting the renamed
+// logs only replays changes the checkpoint already holds.
+
+const (
+	checkpointFile = "checkpoint"
+	logFile        = "wal"
+	// followed by the number of the checkpoint which renamed the log
+	rotatedLogPrefix = logFile + "."
+
+	logPut          = 1
+	logRemove       = 2
+	logRemovePrefix = 3
+
+	logRecordHeaderSize = 8
+)
+
+var ErrClosed = errors.New("radixtree: durable map is closed")
+
+// ErrCheckpointFailed is returned by Close when a checkpoint written after
+// a change failed, and no later checkpoint succeeded.
+var ErrCheckpointFailed = errors.New("radixtree: checkpoint failed")
+
+// SyncPolicy tells when the log is flushed to stable storage. Changes reach
+// the operating system as soon as they are made, so they survive crashes of
+// the process under any policy, but only synced ones survive crashes of the
+// machine.
+type SyncPolicy int
+
+const (
+	// SyncEachWrite syncs the log before every change returns
+	SyncEachWrite SyncPolicy = iota
+	// SyncPeriodically syncs the log in the background, at the sync interval.
+	// If that fails, every later change and Close fail with the error.
+	SyncPeriodically
+	// SyncNever leaves syncing the log to the operating system,
+	// except on checkpoints and on Close
+	SyncNever
+)
+
+// walFile is the part of *os.File the log is written through
+type walFile interface {
+	io.ReadWriteSeeker
+	io.Closer
+	Truncate(size int64) error
+	Sync() error
+}
+
+type durableConfig struct {
+	syncPolicy      SyncPolicy
+	syncInterval    time.Duration
+	checkpointEvery int
+}
+
+// DurableOption changes how a durable map is kept on disk. By default, the
+// log is synced on each write and a checkpoint is written every 10000 changes.
+type DurableOption func(*durableConfig)
+
+func WithSyncPolicy(policy SyncPolicy) DurableOption {
+	return func(config *durableConfig) {
+		config.syncPolicy = policy
+	}
+}
+
+// WithSyncInterval sets how often SyncPeriodically syncs the log,
+// which is every second by default.
+func WithSyncInterval(interval time.Duration) DurableOption {
+	return func(config *durableConfig) {
+		config.syncInterval = interval
+	}
+}
+
+// WithCheckpointEvery sets after how many changes a checkpoint is written,
+// where 0 means only when Checkpoint is called.
+func WithCheckpointEvery(changes int) DurableOption {
+	return func(config *durableConfig) {
+		config.checkpointEvery = changes
+	}
+}
+
+// DurableMap is a Map kept on disk, which is safe for concurrent use.
+// Its changes are logged before they are applied, so that opening the map
+// again after a crash recovers every change that reached the log.
+type DurableMap[V any] struct {
+	mutex sync.RWMutex
+	// held during checkpoints, which run mostly without holding mutex
+	checkpointMutex sync.Mutex
+	m               Map[V]
+	dir             string
+	config          durableConfig
+	log             walFile
+	// number of bytes in the log, and of changes since the last checkpoint
+	logSize int64
+	logged  int
+	// number which the next checkpoint renames the log with
+	nextRotation int
+	closed       bool
+	// set when the log could not be restored after a failed write, or
+	// synced in the background, so that the map refuses any further change
+	failure error
+	// the error of the last automatic checkpoint, if it failed,
+	// guarded by checkpointMutex
+	checkpointErr error
+	// stops the periodic sync
+	stop chan struct{}
+	done chan struct{}
+}
+
+// OpenDurableMap opens the map kept in dir, creating it if it doesn't exist.
+// The values are encoded by codec, or by GobCodec if it is nil.
+func OpenDurableMap[V any](dir string, codec ValueCodec[V], opts ...DurableOption) (*DurableMap[V], error) {
+	dm := &DurableMap[V]{
+		dir: dir,
+		config: durableConfig{
+			syncPolicy:      SyncEachWrite,
+			syncInterval:    time.Second,
+			checkpointEvery: 10000,
+		},
+	}
+	for _, opt := range opts {
+		opt(&dm.config)
+	}
+	dm.m.SetValueCodec(codec)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := dm.loadCheckpoint(); err != nil {
+		return nil, err
+	}
+	if err := dm.replayRotatedLogs(); err != nil {
+		return nil, err
+	}
+
+	log, err := os.OpenFile(filepath.Join(dir, logFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	dm.log = log
+	if err := dm.replay(); err != nil {
+		log.Close()
+		return nil, err
+	}
+
+	if dm.config.syncPolicy == SyncPeriodically {
+		dm.stop = make(chan struct{})
+		dm.done = make(chan struct{})
+		go dm.syncPeriodically()
+	}
+	return dm, nil
+}
+
+func (dm *DurableMap[V]) loadCheckpoint() error {
+	file, err := os.Open(filepath.Join(dm.dir, checkpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := dm.m.Load(file); err != nil {
+		return fmt.Errorf("radixtree: loading checkpoint: %w", err)
+	}
+	return nil
+}
+
+// rotatedLogs returns the numbers of the logs renamed by checkpoints
+// which didn't finish, in increasing order
+func (dm *DurableMap[V]) rotatedLogs() ([]int, error) {
+	entries, err := os.ReadDir(dm.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var rotations []int
+	for _, entry := range entries {
+		suffix, found := strings.CutPrefix(entry.Name(), rotatedLogPrefix)
+		if rotation, err := strconv.Atoi(suffix); found && err == nil && rotation > 0 {
+			rotations = append(rotations, rotation)
+		}
+	}
+	slices.Sort(rotations)
+	return rotations, nil
+}
+
+func rotatedLogName(rotation int) string {
+	return rotatedLogPrefix + strconv.Itoa(rotation)
+}
+
+func (dm *DurableMap[V]) replayRotatedLogs() error {
+	rotations, err := dm.rotatedLogs()
+	if err != nil {
+		return err
+	}
+
+	dm.nextRotation = 1
+	for _, rotation := range rotations {
+		contents, err := os.ReadFile(filepath.Join(dm.dir, rotatedLogName(rotation)))
+		if err != nil {
+			return err
+		}
+		if _, err := dm.replayRecords(contents); err != nil {
+			return err
+		}
+		dm.nextRotation = rotation + 1
+	}
+	return nil
+}
+
+// replayRecords applies the complete records at the start of contents,
+// returning the number of bytes they take
+func (dm *DurableMap[V]) replayRecords(contents []byte) (int, error) {
+	offset := 0
+	for {
+		payload, ok := nextLogRecord(contents[offset:])
+		if !ok {
+			return offset, nil
+		}
+		if err := dm.apply(payload); err != nil {
+			return offset, fmt.Errorf("radixtree: replaying log at offset %d: %w", offset, err)
+		}
+		offset += logRecordHeaderSize + len(payload)
+		dm.logged++
+	}
+}
+
+// replay applies the complete records of the log, cutting off
+// whatever follows them
+func (dm *DurableMap[V]) replay() error {
+	contents, err := io.ReadAll(dm.log)
+	if err != nil {
+		return err
+	}
+
+	offset, err := dm.replayRecords(contents)
+	if err != nil {
+		return err
+	}
+
+	if offset < len(contents) {
+		if err := dm.log.Truncate(int64(offset)); err != nil {
+			return err
+		}
+		if err := dm.log.Sync(); err != nil {
+			return err
+		}
+	}
+	dm.logSize = int64(offset)
+	_, err = dm.log.Seek(int64(offset), io.SeekStart)
+	return err
+}
+
+// nextLogRecord returns the payload of the record at the start of b,
+// if it is complete and intact
+func nextLogRecord(b []byte) ([]byte, bool) {
+	if len(b) < logRecordHeaderSize {
+		return nil, false
+	}
+	length := binary.BigEndian.Uint32(b)
+	checksum := binary.BigEndian.Uint32(b[4:])
+	if uint64(len(b)-logRecordHeaderSize) < uint64(length) {
+		return nil, false
+	}
+	payload := b[logRecordHeaderSize : logRecordHeaderSize+int(length)]
+	if crc32.Checksum(payload, castagnoli) != checksum {
+		return nil, false
+	}
+	return payload, true
+}
+
+func (dm *DurableMap[V]) apply(payload []byte) error {
+	if len(payload) == 0 {
+		return ErrInvalidEncoding
+	}
+	reader := bytes.NewReader(payload[1:])
+	length, err := binary.ReadUvarint(reader)
+	if err != nil || length > uint64(reader.Len()) {
+		return ErrInvalidEncoding
+	}
+	keyStart := len(payload) - reader.Len()
+	key := string(payload[keyStart : keyStart+int(length)])
+	value := payload[keyStart+int(length):]
+
+	switch payload[0] {
+	case logPut:
+		data, err := dm.m.valueCodec().DecodeValue(value)
+		if err != nil {
+			return err
+		}
+		dm.m.Put(key, data)
+	case logRemove:
+		dm.m.Remove(key)
+	case logRemovePrefix:
+		dm.m.RemovePrefix(key)
+	default:
+		return ErrInvalidEncoding
+	}
+	return nil
+}
+
+// usable returns the error every call must fail with, if any
+func (dm *DurableMap[V]) usable() error {
+	if dm.closed {
+		return ErrClosed
+	}
+	return dm.failure
+}
+
+// record appends a change to the log, syncing it if the policy says so.
+// If that fails, the log is cut back to where it was, so that the change
+// is neither replayed later nor followed by garbage.
+func (dm *DurableMap[V]) record(operation byte, key string, value []byte) error {
+	if err := dm.usable(); err != nil {
+		return err
+	}
+
+	payload := []byte{operation}
+	payload = binary.AppendUvarint(payload, uint64(len(key)))
+	payload = append(payload, key...)
+	payload = append(payload, value...)
+
+	record := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	record = binary.BigEndian.AppendUint32(record, crc32.Checksum(payload, castagnoli))
+	record = append(record, payload...)
+
+	_, err := dm.log.Write(record)
+	if err == nil && dm.config.syncPolicy == SyncEachWrite {
+		err = dm.log.Sync()
+	}
+	if err != nil {
+		dm.rollback(err)
+		return err
+	}
+
+	dm.logSize += int64(len(record))
+	dm.logged++
+	return nil
+}
+
+// rollback cuts the log back to its last complete record after a failed
+// write, giving up on the map if even that fails
+func (dm *DurableMap[V]) rollback(cause error) {
+	err := dm.log.Truncate(dm.logSize)
+	if err == nil {
+		_, err = dm.log.Seek(dm.logSize, io.SeekStart)
+	}
+	if err != nil {
+		dm.failure = fmt.Errorf("radixtree: log could not be restored after a failed write: %w", errors.Join(cause, err))
+	}
+}
+
+// write runs change while holding the lock, and then writes a checkpoint if
+// enough changes were logged. The change succeeded even if the checkpoint
+// fails, so that failure is kept for Close instead of being returned.
+func (dm *DurableMap[V]) write(change func() error) error {
+	dm.mutex.Lock()
+	err := change()
+	due := dm.config.checkpointEvery > 0 && dm.logged >= dm.config.checkpointEvery
+	dm.mutex.Unlock()
+
+	// the change made it to the log anyway, and if another checkpoint
+	// is running, it will take the change with it
+	if err != nil || !due || !dm.checkpointMutex.TryLock() {
+		return err
+	}
+	defer dm.checkpointMutex.Unlock()
+
+	if err := dm.checkpoint(); err != nil {
+		dm.checkpointErr = fmt.Errorf("%w: %w", ErrCheckpointFailed, err)
+	} else {
+		dm.checkpointErr = nil
+	}
+	return nil
+}
+
+func (dm *DurableMap[V]) Add(str string, data V) error {
+	return dm.write(func() error {
+		value, err := dm.m.valueCodec().EncodeValue(data)
+		if err != nil {
+			return err
+		}
+		if err := dm.record(logPut, str, value); err != nil {
+			return err
+		}
+		dm.m.Put(str, data)
+		return nil
+	})
+}
+
+func (dm *DurableMap[V]) Remove(str string) error {
+	return dm.write(func() error {
+		if err := dm.usable(); err != nil {
+			return err
+		}
+		if _, exists := dm.m.Get(str); !exists {
+			return nil
+		}
+		if err := dm.record(logRemove, str, nil); err != nil {
+			return err
+		}
+		dm.m.Remove(str)
+		return nil
+	})
+}
+
+func (dm *DurableMap[V]) RemovePrefix(prefix string) (int64, error) {
+	var removed int64
+	err := dm.write(func() error {
+		if err := dm.usable(); err != nil {
+			return err
+		}
+		if dm.m.CountWithPrefix(prefix) == 0 {
+			return nil
+		}
+		if err := dm.record(logRemovePrefix, prefix, nil); err != nil {
+			return err
+		}
+		removed = dm.m.RemovePrefix(prefix)
+		return nil
+	})
+	return removed, err
+}
+
+func (dm *DurableMap[V]) Get(str string) (V, bool) {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+
+	return dm.m.Get(str)
+}
+
+func (dm *DurableMap[V]) Size() int64 {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+
+	return dm.m.Size()
+}
+
+func (dm *DurableMap[V]) LongestPrefix(str string) (string, V, bool) {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+
+	return dm.m.LongestPrefix(str)
+}
+
+// Snapshot returns a frozen view of the map, which is not affected by
+// later changes to it.
+func (dm *DurableMap[V]) Snapshot() ImmutableMap[V] {
+	dm.mutex.Lock()
+	defer dm.mutex.Unlock()
+
+	return dm.m.Snapshot()
+}
+
+// ForEach visits a snapshot of the map, so action may change the map.
+func (dm *DurableMap[V]) ForEach(action func(string, V)) {
+	dm.Snapshot().ForEach(action)
+}
+
+// ForEachWithPrefix visits a snapshot of the map, so action may
+// change the map.
+func (dm *DurableMap[V]) ForEachWithPrefix(prefix string, action func(string, V)) {
+	dm.Snapshot().ForEachWithPrefix(prefix, action)
+}
+
+// Checkpoint writes the whole map to disk and deletes the log, so that
+// opening the map doesn't need to replay it. Other calls only wait for it
+// to start a new log, not for the map to be written.
+func (dm *DurableMap[V]) Checkpoint() error {
+	dm.checkpointMutex.Lock()
+	defer dm.checkpointMutex.Unlock()
+
+	err := dm.checkpoint()
+	if err == nil {
+		dm.checkpointErr = nil
+	}
+	return err
+}
+
+// checkpoint must be called while holding checkpointMutex
+func (dm *DurableMap[V]) checkpoint() error {
+	dm.mutex.Lock()
+	if err := dm.usable(); err != nil {
+		dm.mutex.Unlock()
+		return err
+	}
+	if dm.logged == 0 {
+		dm.mutex.Unlock()
+		return nil
+	}
+	snapshot := dm.m.Snapshot()
+	rotation, err := dm.rotateLog()
+	dm.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomically(filepath.Join(dm.dir, checkpointFile), snapshot.m.Save); err != nil {
+		return err
+	}
+
+	// the renamed logs up to this one only hold changes in the checkpoint
+	rotations, err := dm.rotatedLogs()
+	if err != nil {
+		return err
+	}
+	for _, r := range rotations {
+		if r > rotation {
+			break
+		}
+		if err := os.Remove(filepath.Join(dm.dir, rotatedLogName(r))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rotateLog renames the log and starts a new one, returning the number it was
+// renamed with. It must be called while holding mutex, and gives up on the map
+// if it fails after closing the log.
+func (dm *DurableMap[V]) rotateLog() (int, error) {
+	if err := dm.log.Sync(); err != nil {
+		return 0, err
+	}
+
+	rotation := dm.nextRotation
+	path := filepath.Join(dm.dir, logFile)
+	err := dm.log.Close()
+	if err == nil {
+		err = os.Rename(path, filepath.Join(dm.dir, rotatedLogName(rotation)))
+	}
+	var log *os.File
+	if err == nil {
+		log, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	}
+	if err == nil {
+		err = syncDir(dm.dir)
+	}
+	if err != nil {
+		if log != nil {
+			log.Close()
+		}
+		dm.failure = fmt.Errorf("radixtree: log could not be renamed: %w", err)
+		return 0, dm.failure
+	}
+
+	dm.log = log
+	dm.logSize = 0
+	dm.logged = 0
+	dm.nextRotation++
+	return rotation, nil
+}
+
+// writeFileAtomically replaces the file at path with the contents written by
+// write, so that a crash leaves either the old or the new contents
+func writeFileAtomically(path string, write func(io.Writer) error) error {
+	temporary := path + ".tmp"
+	file, err := os.Create(temporary)
+	if err != nil {
+		return err
+	}
+
+	err = write(file)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporary, path)
+	}
+	if err != nil {
+		os.Remove(temporary)
+		return err
+	}
+
+	// the rename itself must reach the disk too
+	return syncDir(filepath.Dir(path))
+}
+
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+func (dm *DurableMap[V]) syncPeriodically() {
+	defer close(dm.done)
+
+	ticker := time.NewTicker(dm.config.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			dm.sync()
+		case <-dm.stop:
+			return
+		}
+	}
+}
+
+// sync syncs the log for SyncPeriodically. Changes which returned since the
+// last sync may be lost if it fails, so the map then refuses any further change.
+func (dm *DurableMap[V]) sync() {
+	// writers and log rotations are kept out, but not readers
+	dm.mutex.RLock()
+	var err error
+	if dm.usable() == nil {
+		err = dm.log.Sync()
+	}
+	dm.mutex.RUnlock()
+	if err == nil {
+		return
+	}
+
+	dm.mutex.Lock()
+	if dm.failure == nil {
+		dm.failure = fmt.Errorf("radixtree: log could not be synced: %w", err)
+	}
+	dm.mutex.Unlock()
+}
+
+// Close waits for any running checkpoint, syncs the log and closes the map.
+// It also reports a failed automatic checkpoint, wrapped in ErrCheckpointFailed.
+func (dm *DurableMap[V]) Close() error {
+	dm.checkpointMutex.Lock()
+	defer dm.checkpointMutex.Unlock()
+
+	dm.mutex.Lock()
+	if dm.closed {
+		dm.mutex.Unlock()
+		return ErrClosed
+	}
+	dm.closed = true
+	dm.mutex.Unlock()
+
+	if dm.stop != nil {
+		close(dm.stop)
+		<-dm.done
+	}
+
+	err := dm.failure
+	if err == nil {
+		err = dm.log.Sync()
+	}
+	if closeErr := dm.log.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = dm.checkpointErr
+	}
+	return err
+}
@@ -0,0 +1,147 @@
+package radixtree
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingLog makes the next write, sync or truncation of a log fail,
+// with writes failing after writing half of their bytes
+type failingLog struct {
+	*os.File
+	failWrite, failSync, failTruncate bool
+}
+
+var errInjected = errors.New("injected failure")
+
+func (fl *failingLog) Write(p []byte) (int, error) {
+	if fl.failWrite {
+		fl.failWrite = false
+		n, _ := fl.File.Write(p[:len(p)/2])
+		return n, errInjected
+	}
+	return fl.File.Write(p)
+}
+
+func (fl *failingLog) Sync() error {
+	if fl.failSync {
+		fl.failSync = false
+		return errInjected
+	}
+	return fl.File.Sync()
+}
+
+func (fl *failingLog) Truncate(size int64) error {
+	if fl.failTruncate {
+		fl.failTruncate = false
+		return errInjected
+	}
+	return fl.File.Truncate(size)
+}
+
+func openFailing(t *testing.T, dir string, opts ...DurableOption) (*DurableMap[string], *failingLog) {
+	dm, err := OpenDurableMap[string](dir, nil, opts...)
+	assert.NoError(t, err)
+	log := &failingLog{File: dm.log.(*os.File)}
+	// the periodic sync may be running already
+	dm.mutex.Lock()
+	dm.log = log
+	dm.mutex.Unlock()
+	return dm, log
+}
+
+func contentsAfterReopening(t *testing.T, dir string) map[string]string {
+	dm, err := OpenDurableMap[string](dir, nil)
+	assert.NoError(t, err)
+	defer dm.Close()
+
+	contents := map[string]string{}
+	dm.ForEach(func(key, data string) {
+		contents[key] = data
+	})
+	return contents
+}
+
+func TestDurableMapFailedWrites(t *testing.T) {
+	t.Parallel()
+
+	t.Run("partial write", func(t *testing.T) {
+		dir := t.TempDir()
+		dm, log := openFailing(t, dir)
+
+		assert.NoError(t, dm.Add("romane", "1"))
+		log.failWrite = true
+		assert.Equal(t, errInjected, dm.Add("romanus", "2"))
+		_, exists := dm.Get("romanus")
+		assert.False(t, exists)
+
+		// the change after the failed one is not lost behind its partial record
+		assert.NoError(t, dm.Add("romulus", "3"))
+		assert.NoError(t, dm.Close())
+
+		assert.Equal(t, map[string]string{"romane": "1", "romulus": "3"}, contentsAfterReopening(t, dir))
+	})
+
+	t.Run("failed sync", func(t *testing.T) {
+		dir := t.TempDir()
+		dm, log := openFailing(t, dir)
+
+		assert.NoError(t, dm.Add("romane", "1"))
+		log.failSync = true
+		assert.Equal(t, errInjected, dm.Add("romanus", "2"))
+		_, exists := dm.Get("romanus")
+		assert.False(t, exists)
+		assert.NoError(t, dm.Close())
+
+		// the change which failed does not come back
+		assert.Equal(t, map[string]string{"romane": "1"}, contentsAfterReopening(t, dir))
+	})
+
+	t.Run("failed periodic sync", func(t *testing.T) {
+		dir := t.TempDir()
+		dm, log := openFailing(t, dir, WithSyncPolicy(SyncPeriodically), WithSyncInterval(time.Millisecond))
+
+		assert.NoError(t, dm.Add("romane", "1"))
+		dm.mutex.Lock()
+		log.failSync = true
+		dm.mutex.Unlock()
+		assert.Eventually(t, func() bool {
+			dm.mutex.RLock()
+			defer dm.mutex.RUnlock()
+			return dm.failure != nil
+		}, time.Second, time.Millisecond)
+
+		// the next write and Close report the failure
+		err := dm.Add("romanus", "2")
+		assert.Error(t, err)
+		assert.True(t, errors.Is(err, errInjected))
+		assert.Equal(t, err, dm.Close())
+	})
+
+	t.Run("failed rollback", func(t *testing.T) {
+		dir := t.TempDir()
+		dm, log := openFailing(t, dir)
+
+		assert.NoError(t, dm.Add("romane", "1"))
+		log.failWrite, log.failTruncate = true, true
+		assert.Equal(t, errInjected, dm.Add("romanus", "2"))
+
+		// the map refuses any further change
+		err := dm.Add("romulus", "3")
+		assert.Error(t, err)
+		assert.True(t, errors.Is(err, errInjected))
+		assert.Equal(t, err, dm.Remove("romane"))
+		_, removeErr := dm.RemovePrefix("rom")
+		assert.Equal(t, err, removeErr)
+		assert.Equal(t, err, dm.Checkpoint())
+		assert.Equal(t, err, dm.Close())
+
+		data, exists := dm.Get("romane")
+		assert.True(t, exists)
+		assert.Equal(t, "1", data)
+	})
+}
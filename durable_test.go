@@ -0,0 +1,302 @@
+package radixtree_test
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+func openDurable(t *testing.T, dir string, opts ...radixtree.DurableOption) *radixtree.DurableMap[int] {
+	dm, err := radixtree.OpenDurableMap[int](dir, decimalCodec{}, opts...)
+	assert.NoError(t, err)
+	return dm
+}
+
+func collectDurable(dm *radixtree.DurableMap[int]) map[string]int {
+	contents := map[string]int{}
+	dm.ForEach(func(key string, data int) {
+		contents[key] = data
+	})
+	return contents
+}
+
+// applyChanges makes a sequence of changes, returning the expected
+// contents and the size of the log after each one
+func applyChanges(t *testing.T, dm *radixtree.DurableMap[int], dir string) ([]map[string]int, []int64) {
+	model := map[string]int{}
+	models := []map[string]int{maps.Clone(model)}
+	sizes := []int64{0}
+
+	for i := 0; i < 30; i++ {
+		key := "key/" + strconv.Itoa(i%11)
+		switch {
+		case i%7 == 6:
+			_, err := dm.RemovePrefix("key/1")
+			assert.NoError(t, err)
+			for k := range model {
+				if len(k) >= 5 && k[:5] == "key/1" {
+					delete(model, k)
+				}
+			}
+		case i%5 == 4:
+			assert.NoError(t, dm.Remove(key))
+			delete(model, key)
+		default:
+			assert.NoError(t, dm.Add(key, i))
+			model[key] = i
+		}
+
+		info, err := os.Stat(filepath.Join(dir, "wal"))
+		assert.NoError(t, err)
+		models = append(models, maps.Clone(model))
+		sizes = append(sizes, info.Size())
+	}
+	return models, sizes
+}
+
+func TestDurableMap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("survives reopening", func(t *testing.T) {
+		dir := t.TempDir()
+
+		dm := openDurable(t, dir)
+		assert.NoError(t, dm.Add("romane", 1))
+		assert.NoError(t, dm.Add("romanus", 2))
+		assert.NoError(t, dm.Add("romulus", 3))
+		assert.NoError(t, dm.Remove("romanus"))
+		assert.NoError(t, dm.Close())
+
+		dm = openDurable(t, dir)
+		defer dm.Close()
+		assert.Equal(t, map[string]int{"romane": 1, "romulus": 3}, collectDurable(dm))
+		assert.EqualValues(t, 2, dm.Size())
+
+		key, data, found := dm.LongestPrefix("romulusness")
+		assert.True(t, found)
+		assert.Equal(t, "romulus", key)
+		assert.EqualValues(t, 3, data)
+	})
+
+	t.Run("crash truncating the log", func(t *testing.T) {
+		dir := t.TempDir()
+
+		dm := openDurable(t, dir, radixtree.WithCheckpointEvery(0))
+		models, sizes := applyChanges(t, dm, dir)
+		// the log as a crash at this point would leave it
+		log, err := os.ReadFile(filepath.Join(dir, "wal"))
+		assert.NoError(t, err)
+		assert.NoError(t, dm.Close())
+
+		changes := 0
+		for length := int64(0); length <= int64(len(log)); length++ {
+			for changes+1 < len(sizes) && sizes[changes+1] <= length {
+				changes++
+			}
+
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, "wal"), log[:length], 0o644))
+			recovered := openDurable(t, dir)
+			assert.Equal(t, models[changes], collectDurable(recovered), "log length %d", length)
+
+			// the torn record is cut off, so new changes are not lost behind it
+			assert.NoError(t, recovered.Add("after", 1))
+			assert.NoError(t, recovered.Close())
+			recovered = openDurable(t, dir)
+			data, exists := recovered.Get("after")
+			assert.True(t, exists)
+			assert.EqualValues(t, 1, data)
+			assert.NoError(t, recovered.Close())
+		}
+	})
+
+	t.Run("corrupted last record", func(t *testing.T) {
+		dir := t.TempDir()
+
+		dm := openDurable(t, dir)
+		models, _ := applyChanges(t, dm, dir)
+		assert.NoError(t, dm.Close())
+
+		path := filepath.Join(dir, "wal")
+		log, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		log[len(log)-1] ^= 0xff
+		assert.NoError(t, os.WriteFile(path, log, 0o644))
+
+		dm = openDurable(t, dir)
+		defer dm.Close()
+		assert.Equal(t, models[len(models)-2], collectDurable(dm))
+	})
+
+	t.Run("checkpoints", func(t *testing.T) {
+		dir := t.TempDir()
+
+		dm := openDurable(t, dir, radixtree.WithCheckpointEvery(10))
+		models, sizes := applyChanges(t, dm, dir)
+		assert.NoError(t, dm.Close())
+
+		_, err := os.Stat(filepath.Join(dir, "checkpoint"))
+		assert.NoError(t, err)
+		// the log only holds the changes after the last checkpoint
+		assert.Less(t, sizes[len(sizes)-1], sizes[9])
+
+		dm = openDurable(t, dir)
+		defer dm.Close()
+		assert.Equal(t, models[len(models)-1], collectDurable(dm))
+	})
+
+	t.Run("crash between checkpoint and emptying the log", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "wal")
+
+		dm := openDurable(t, dir, radixtree.WithCheckpointEvery(0))
+		models, _ := applyChanges(t, dm, dir)
+		log, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.NoError(t, dm.Checkpoint())
+		assert.NoError(t, dm.Close())
+
+		// the log still holds the changes in the checkpoint
+		assert.NoError(t, os.WriteFile(path, log, 0o644))
+
+		dm = openDurable(t, dir)
+		defer dm.Close()
+		assert.Equal(t, models[len(models)-1], collectDurable(dm))
+	})
+
+	t.Run("crash between renaming the log and writing the checkpoint", func(t *testing.T) {
+		dir := t.TempDir()
+
+		dm := openDurable(t, dir, radixtree.WithCheckpointEvery(0))
+		assert.NoError(t, dm.Add("romane", 1))
+		assert.NoError(t, dm.Add("romanus", 2))
+		assert.NoError(t, dm.Close())
+		// what a checkpoint leaves behind before writing the map
+		assert.NoError(t, os.Rename(filepath.Join(dir, "wal"), filepath.Join(dir, "wal.1")))
+
+		dm = openDurable(t, dir, radixtree.WithCheckpointEvery(0))
+		assert.NoError(t, dm.Remove("romane"))
+		assert.NoError(t, dm.Add("romulus", 3))
+		assert.NoError(t, dm.Close())
+
+		dm = openDurable(t, dir, radixtree.WithCheckpointEvery(0))
+		assert.Equal(t, map[string]int{"romanus": 2, "romulus": 3}, collectDurable(dm))
+
+		// the next checkpoint takes over the renamed log
+		assert.NoError(t, dm.Checkpoint())
+		_, err := os.Stat(filepath.Join(dir, "wal.1"))
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filepath.Join(dir, "wal.2"))
+		assert.True(t, os.IsNotExist(err))
+		assert.NoError(t, dm.Close())
+
+		dm = openDurable(t, dir)
+		defer dm.Close()
+		assert.Equal(t, map[string]int{"romanus": 2, "romulus": 3}, collectDurable(dm))
+	})
+
+	t.Run("failed checkpoints", func(t *testing.T) {
+		dir := t.TempDir()
+		// keeps the checkpoint from being written
+		blocker := filepath.Join(dir, "checkpoint.tmp")
+		assert.NoError(t, os.Mkdir(blocker, 0o755))
+
+		dm := openDurable(t, dir, radixtree.WithCheckpointEvery(2))
+		// the changes succeed even though their checkpoint doesn't
+		assert.NoError(t, dm.Add("romane", 1))
+		assert.NoError(t, dm.Add("romanus", 2))
+		err := dm.Close()
+		assert.True(t, errors.Is(err, radixtree.ErrCheckpointFailed))
+
+		dm = openDurable(t, dir, radixtree.WithCheckpointEvery(2))
+		assert.Equal(t, map[string]int{"romane": 1, "romanus": 2}, collectDurable(dm))
+		assert.NoError(t, dm.Add("romulus", 3))
+		assert.NoError(t, dm.Add("rubens", 4))
+
+		// a checkpoint which succeeds clears the failure
+		assert.NoError(t, os.Remove(blocker))
+		assert.NoError(t, dm.Checkpoint())
+		assert.NoError(t, dm.Close())
+
+		dm = openDurable(t, dir)
+		defer dm.Close()
+		assert.Equal(t, map[string]int{"romane": 1, "romanus": 2, "romulus": 3, "rubens": 4}, collectDurable(dm))
+	})
+
+	t.Run("checkpoints while writing", func(t *testing.T) {
+		dir := t.TempDir()
+
+		const writers, keys = 4, 200
+
+		dm := openDurable(t, dir, radixtree.WithSyncPolicy(radixtree.SyncNever), radixtree.WithCheckpointEvery(50))
+		var wg sync.WaitGroup
+		for w := 0; w < writers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < keys; i++ {
+					assert.NoError(t, dm.Add(fmt.Sprintf("w%d/%d", w, i), i))
+					dm.Get(fmt.Sprintf("w%d/%d", w, i/2))
+				}
+			}(w)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				assert.NoError(t, dm.Checkpoint())
+			}
+		}()
+		wg.Wait()
+		assert.NoError(t, dm.Close())
+
+		dm = openDurable(t, dir)
+		defer dm.Close()
+		assert.EqualValues(t, writers*keys, dm.Size())
+	})
+
+	t.Run("sync policies", func(t *testing.T) {
+		policies := []radixtree.SyncPolicy{radixtree.SyncEachWrite, radixtree.SyncPeriodically, radixtree.SyncNever}
+		for _, policy := range policies {
+			dir := t.TempDir()
+
+			dm := openDurable(t, dir, radixtree.WithSyncPolicy(policy), radixtree.WithSyncInterval(time.Millisecond))
+			models, _ := applyChanges(t, dm, dir)
+			time.Sleep(5 * time.Millisecond)
+			assert.NoError(t, dm.Close())
+
+			dm = openDurable(t, dir)
+			assert.Equal(t, models[len(models)-1], collectDurable(dm))
+			assert.NoError(t, dm.Close())
+		}
+	})
+
+	t.Run("callbacks can change the map", func(t *testing.T) {
+		dm := openDurable(t, t.TempDir())
+		defer dm.Close()
+
+		assert.NoError(t, dm.Add("a", 1))
+		dm.ForEach(func(key string, data int) {
+			assert.NoError(t, dm.Add(key+key, data*10))
+		})
+		assert.Equal(t, map[string]int{"a": 1, "aa": 10}, collectDurable(dm))
+	})
+
+	t.Run("closed map", func(t *testing.T) {
+		dm := openDurable(t, t.TempDir())
+		assert.NoError(t, dm.Close())
+
+		assert.Equal(t, radixtree.ErrClosed, dm.Add("a", 1))
+		assert.Equal(t, radixtree.ErrClosed, dm.Remove("a"))
+		assert.Equal(t, radixtree.ErrClosed, dm.Checkpoint())
+		assert.Equal(t, radixtree.ErrClosed, dm.Close())
+	})
+}
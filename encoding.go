@@ -0,0 +1,324 @@
+package radixtree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"io"
+	"math"
+	"slices"
+)
+
+var ErrInvalidEncoding = errors.New("radixtree: invalid encoding")
+
+// ValueCodec converts the values of a Map to and from bytes when the map
+// is serialized. The bytes given to DecodeValue are only valid during the call.
+type ValueCodec[V any] interface {
+	EncodeValue(data V) ([]byte, error)
+	DecodeValue(encoded []byte) (V, error)
+}
+
+// GobCodec encodes values with encoding/gob. It is the codec
+// maps use unless they are given another one.
+type GobCodec[V any] struct{}
+
+func (GobCodec[V]) EncodeValue(data V) ([]byte, error) {
+	var buffer bytes.Buffer
+	err := gob.NewEncoder(&buffer).Encode(&data)
+	return buffer.Bytes(), err
+}
+
+func (GobCodec[V]) DecodeValue(encoded []byte) (V, error) {
+	var data V
+	err := gob.NewDecoder(bytes.NewReader(encoded)).Decode(&data)
+	return data, err
+}
+
+// The encoding of WriteTo is the length (uvarint) of the encoded tree
+// followed by it, so that readers know how much to read. The tree is the
+// preorder of its nodes, preceded by a byte telling whether it is empty.
+// Each node is encoded as
+//
+//	part length (uvarint) | part | final (byte) | value | child count (uvarint)
+//
+// where the value, only present in final nodes of maps, is its length
+// (uvarint) followed by the bytes of the value codec.
+
+type treeWriter struct {
+	w       *bufio.Writer
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (tw *treeWriter) writeUvarint(x uint64) error {
+	n := binary.PutUvarint(tw.scratch[:], x)
+	_, err := tw.w.Write(tw.scratch[:n])
+	return err
+}
+
+func (tw *treeWriter) writeString(str string) error {
+	if err := tw.writeUvarint(uint64(len(str))); err != nil {
+		return err
+	}
+	_, err := tw.w.WriteString(str)
+	return err
+}
+
+// encodeTree writes the tree to w, without its length.
+// Values are skipped when encodeValue is nil.
+func encodeTree[V any](w io.Writer, root *radixNode[V], encodeValue func(V) ([]byte, error)) error {
+	tw := &treeWriter{w: bufio.NewWriter(w)}
+
+	err := tw.w.WriteByte(boolByte(root != nil))
+	if err == nil && root != nil {
+		err = encodeNode(tw, root, encodeValue)
+	}
+	if err == nil {
+		err = tw.w.Flush()
+	}
+	return err
+}
+
+func encodeNode[V any](tw *treeWriter, node *radixNode[V], encodeValue func(V) ([]byte, error)) error {
+	if err := tw.writeString(node.part); err != nil {
+		return err
+	}
+	if err := tw.w.WriteByte(boolByte(node.final)); err != nil {
+		return err
+	}
+	if node.final && encodeValue != nil {
+		encoded, err := encodeValue(node.data)
+		if err != nil {
+			return err
+		}
+		if err := tw.writeUvarint(uint64(len(encoded))); err != nil {
+			return err
+		}
+		if _, err := tw.w.Write(encoded); err != nil {
+			return err
+		}
+	}
+	if err := tw.writeUvarint(uint64(len(node.children))); err != nil {
+		return err
+	}
+	for _, child := range node.children {
+		if err := encodeNode(tw, child, encodeValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// writeTree writes the tree preceded by its length, returning
+// the number of bytes written
+func writeTree[V any](w io.Writer, root *radixNode[V], encodeValue func(V) ([]byte, error)) (int64, error) {
+	var tree bytes.Buffer
+	if err := encodeTree(&tree, root, encodeValue); err != nil {
+		return 0, err
+	}
+
+	length := binary.AppendUvarint(nil, uint64(tree.Len()))
+	n, err := w.Write(length)
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := tree.WriteTo(w)
+	return int64(n) + m, err
+}
+
+// countingByteReader reads single bytes, without taking anything
+// past them from the underlying reader, and counts them
+type countingByteReader struct {
+	r io.Reader
+	b [1]byte
+	n int64
+}
+
+func (cr *countingByteReader) ReadByte() (byte, error) {
+	var err error
+	if br, ok := cr.r.(io.ByteReader); ok {
+		cr.b[0], err = br.ReadByte()
+	} else {
+		_, err = io.ReadFull(cr.r, cr.b[:])
+	}
+	if err == nil {
+		cr.n++
+	}
+	return cr.b[0], err
+}
+
+// readFull reads exactly n bytes from r, or fails with io.ErrUnexpectedEOF.
+// The buffer grows in bounded steps as the bytes arrive, so a corrupted
+// length can't make us allocate much more than r holds.
+func readFull(r io.Reader, n uint64) ([]byte, error) {
+	if n > math.MaxInt {
+		return nil, ErrInvalidEncoding
+	}
+
+	const step = 1 << 20
+	buffer := make([]byte, 0, min(int(n), step))
+	for len(buffer) < int(n) {
+		if len(buffer) == cap(buffer) {
+			buffer = slices.Grow(buffer, min(int(n)-len(buffer), len(buffer)))
+		}
+		read, err := io.ReadFull(r, buffer[len(buffer):min(cap(buffer), int(n))])
+		buffer = buffer[:len(buffer)+read]
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buffer, nil
+}
+
+// readTree reads a tree written by writeTree, returning it
+// with the number of bytes read
+func readTree[V any](r io.Reader, gen uint64, decodeValue func([]byte) (V, error)) (*radixNode[V], int64, error) {
+	// the length is read byte by byte, which is what keeps
+	// the tree from being read past its end
+	counter := &countingByteReader{r: r}
+	length, err := binary.ReadUvarint(counter)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	n := counter.n
+	if err != nil {
+		return nil, n, err
+	}
+
+	tree, err := readFull(r, length)
+	n += int64(len(tree))
+	if err != nil {
+		return nil, n, err
+	}
+	root, err := decodeTree(tree, gen, decodeValue)
+	return root, n, err
+}
+
+// unmarshalTree decodes data, which must hold exactly a tree written by writeTree
+func unmarshalTree[V any](data []byte, gen uint64, decodeValue func([]byte) (V, error)) (*radixNode[V], error) {
+	length, n := binary.Uvarint(data)
+	if n <= 0 || length != uint64(len(data)-n) {
+		return nil, ErrInvalidEncoding
+	}
+	return decodeTree(data[n:], gen, decodeValue)
+}
+
+// treeReader decodes a tree held in memory, so the parts are built straight
+// from its bytes
+type treeReader struct {
+	data []byte
+}
+
+func (tr *treeReader) readUvarint() (uint64, error) {
+	x, n := binary.Uvarint(tr.data)
+	if n <= 0 {
+		return 0, ErrInvalidEncoding
+	}
+	tr.data = tr.data[n:]
+	return x, nil
+}
+
+func (tr *treeReader) readBool() (bool, error) {
+	if len(tr.data) == 0 || tr.data[0] > 1 {
+		return false, ErrInvalidEncoding
+	}
+	b := tr.data[0] == 1
+	tr.data = tr.data[1:]
+	return b, nil
+}
+
+func (tr *treeReader) readBytes() ([]byte, error) {
+	length, err := tr.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(tr.data)) {
+		return nil, ErrInvalidEncoding
+	}
+	b := tr.data[:length]
+	tr.data = tr.data[length:]
+	return b, nil
+}
+
+// decodeTree decodes a tree written by encodeTree, which must take all
+// of data, and whose nodes are owned by the generation gen.
+// Values are skipped when decodeValue is nil.
+func decodeTree[V any](data []byte, gen uint64, decodeValue func([]byte) (V, error)) (*radixNode[V], error) {
+	tr := &treeReader{data: data}
+
+	present, err := tr.readBool()
+	if err != nil {
+		return nil, err
+	}
+	var root *radixNode[V]
+	if present {
+		if root, err = decodeNode(tr, true, gen, decodeValue); err != nil {
+			return nil, err
+		}
+	}
+	if len(tr.data) != 0 {
+		return nil, ErrInvalidEncoding
+	}
+	return root, nil
+}
+
+func decodeNode[V any](tr *treeReader, isRoot bool, gen uint64, decodeValue func([]byte) (V, error)) (*radixNode[V], error) {
+	part, err := tr.readBytes()
+	if err != nil {
+		return nil, err
+	}
+	if len(part) == 0 && !isRoot {
+		return nil, ErrInvalidEncoding
+	}
+
+	final, err := tr.readBool()
+	if err != nil {
+		return nil, err
+	}
+	var data V
+	if final && decodeValue != nil {
+		encoded, err := tr.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		if data, err = decodeValue(encoded); err != nil {
+			return nil, err
+		}
+	}
+	node := newRadixNode(string(part), final, data, gen)
+
+	childCount, err := tr.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	// children start with distinct bytes, and nodes which aren't
+	// words only exist to split the words below them
+	if childCount > 256 || (!final && childCount < 2) {
+		return nil, ErrInvalidEncoding
+	}
+
+	node.children = make([]*radixNode[V], 0, childCount)
+	for i := uint64(0); i < childCount; i++ {
+		child, err := decodeNode(tr, false, gen, decodeValue)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && node.children[i-1].part[0] >= child.part[0] {
+			return nil, ErrInvalidEncoding
+		}
+		node.children = append(node.children, child)
+		node.count += child.count
+	}
+	return node, nil
+}
@@ -0,0 +1,269 @@
+package radixtree_test
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"io"
+	"maps"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = &radixtree.Map[int]{}
+	_ encoding.BinaryUnmarshaler = &radixtree.Map[int]{}
+	_ io.WriterTo                = &radixtree.Set{}
+	_ io.ReaderFrom              = &radixtree.Set{}
+)
+
+type decimalCodec struct{}
+
+func (decimalCodec) EncodeValue(data int) ([]byte, error) {
+	return []byte(strconv.Itoa(data)), nil
+}
+
+func (decimalCodec) DecodeValue(encoded []byte) (int, error) {
+	return strconv.Atoi(string(encoded))
+}
+
+func TestMapBinary(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		random := rand.New(rand.NewSource(42))
+		rmap := radixtree.Map[int]{}
+		for i := 0; i < 1000; i++ {
+			rmap.Put(strconv.FormatInt(int64(random.Intn(5000)), 4), i)
+		}
+		rmap.Put("", -1)
+
+		encoded, err := rmap.MarshalBinary()
+		assert.NoError(t, err)
+
+		decoded := radixtree.Map[int]{}
+		assert.NoError(t, decoded.UnmarshalBinary(encoded))
+
+		assert.Equal(t, maps.Collect(rmap.All()), maps.Collect(decoded.All()))
+		assert.Equal(t, rmap.Size(), decoded.Size())
+		assert.Equal(t, rmap.Rank("123"), decoded.Rank("123"))
+
+		// the decoded tree keeps working
+		decoded.Put("0123", 7)
+		decoded.RemovePrefix("1")
+		rmap.Put("0123", 7)
+		rmap.RemovePrefix("1")
+		assert.Equal(t, maps.Collect(rmap.All()), maps.Collect(decoded.All()))
+	})
+
+	t.Run("empty map", func(t *testing.T) {
+		rmap := radixtree.Map[string]{}
+
+		encoded, err := rmap.MarshalBinary()
+		assert.NoError(t, err)
+
+		decoded := radixtree.Map[string]{}
+		decoded.Add("stale", "value")
+		assert.NoError(t, decoded.UnmarshalBinary(encoded))
+		assert.EqualValues(t, 0, decoded.Size())
+		assert.Empty(t, slices.Collect(decoded.Keys()))
+	})
+
+	t.Run("custom value codec", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		rmap.SetValueCodec(decimalCodec{})
+		rmap.Add("answer", 42)
+
+		var buffer bytes.Buffer
+		_, err := rmap.WriteTo(&buffer)
+		assert.NoError(t, err)
+		assert.Contains(t, buffer.String(), "42")
+
+		decoded := radixtree.Map[int]{}
+		decoded.SetValueCodec(decimalCodec{})
+		_, err = decoded.ReadFrom(&buffer)
+		assert.NoError(t, err)
+
+		data, exists := decoded.Get("answer")
+		assert.True(t, exists)
+		assert.EqualValues(t, 42, data)
+	})
+
+	t.Run("streams hold several maps", func(t *testing.T) {
+		first := radixtree.Map[string]{}
+		first.Add("romane", "1")
+		first.Add("romanus", "2")
+		second := radixtree.Map[string]{}
+		second.Add("rubens", "3")
+
+		var buffer bytes.Buffer
+		written, err := first.WriteTo(&buffer)
+		assert.NoError(t, err)
+		assert.EqualValues(t, buffer.Len(), written)
+		_, err = second.WriteTo(&buffer)
+		assert.NoError(t, err)
+
+		decodedFirst, decodedSecond := radixtree.Map[string]{}, radixtree.Map[string]{}
+		read, err := decodedFirst.ReadFrom(&buffer)
+		assert.NoError(t, err)
+		assert.Equal(t, written, read)
+		_, err = decodedSecond.ReadFrom(&buffer)
+		assert.NoError(t, err)
+
+		assert.Equal(t, maps.Collect(first.All()), maps.Collect(decodedFirst.All()))
+		assert.Equal(t, maps.Collect(second.All()), maps.Collect(decodedSecond.All()))
+	})
+
+	t.Run("invalid data leaves the map unchanged", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		rmap.SetValueCodec(decimalCodec{})
+		rmap.Add("romane", 1)
+		rmap.Add("romanus", 2)
+		encoded, _ := rmap.MarshalBinary()
+
+		decoded := radixtree.Map[int]{}
+		decoded.SetValueCodec(decimalCodec{})
+		decoded.Add("kept", 1)
+
+		for i := 0; i < len(encoded); i++ {
+			err := decoded.UnmarshalBinary(encoded[:i])
+			assert.Error(t, err)
+		}
+		assert.Equal(t, radixtree.ErrInvalidEncoding, decoded.UnmarshalBinary(append(encoded, 0)))
+		assert.Equal(t, map[string]int{"kept": 1}, maps.Collect(decoded.All()))
+	})
+}
+
+func TestSetBinary(t *testing.T) {
+	t.Parallel()
+
+	t.Run("files hold several sets", func(t *testing.T) {
+		first, second := radixtree.Set{}, radixtree.Set{}
+		first.Add("romane")
+		first.Add("romanus")
+		second.Add("rubens")
+
+		file, err := os.Create(filepath.Join(t.TempDir(), "sets"))
+		assert.NoError(t, err)
+		defer file.Close()
+
+		written, err := first.WriteTo(file)
+		assert.NoError(t, err)
+		_, err = second.WriteTo(file)
+		assert.NoError(t, err)
+		_, err = file.Seek(0, io.SeekStart)
+		assert.NoError(t, err)
+
+		decodedFirst, decodedSecond := radixtree.Set{}, radixtree.Set{}
+		read, err := decodedFirst.ReadFrom(file)
+		assert.NoError(t, err)
+		assert.Equal(t, written, read)
+		_, err = decodedSecond.ReadFrom(file)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []string{"romane", "romanus"}, slices.Collect(decodedFirst.All()))
+		assert.Equal(t, []string{"rubens"}, slices.Collect(decodedSecond.All()))
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		random := rand.New(rand.NewSource(42))
+		set := radixtree.Set{}
+		for i := 0; i < 1000; i++ {
+			set.Add(strconv.FormatInt(int64(random.Intn(5000)), 4))
+		}
+
+		encoded, err := set.MarshalBinary()
+		assert.NoError(t, err)
+
+		decoded := radixtree.Set{}
+		assert.NoError(t, decoded.UnmarshalBinary(encoded))
+		assert.Equal(t, slices.Collect(set.All()), slices.Collect(decoded.All()))
+		assert.Equal(t, set.Size(), decoded.Size())
+	})
+
+	t.Run("malformed trees are rejected", func(t *testing.T) {
+		// the encoding of a tree starts with its length
+		withLength := func(tree ...byte) []byte {
+			return append([]byte{byte(len(tree))}, tree...)
+		}
+
+		malformed := map[string][]byte{
+			"invalid flag":            withLength(2),
+			"child with empty part":   withLength(1, 0, 1, 1, 0, 1, 0),
+			"unsorted children":       withLength(1, 0, 0, 2, 1, 'b', 1, 0, 1, 'a', 1, 0),
+			"repeated first byte":     withLength(1, 0, 0, 2, 1, 'a', 1, 0, 2, 'a', 'b', 1, 0),
+			"non-word with one child": withLength(1, 1, 'a', 0, 1, 1, 'b', 1, 0),
+			"truncated part":          withLength(1, 5, 'a'),
+			"truncated tree":          {5, 1, 1, 'a', 1},
+		}
+
+		for name, data := range malformed {
+			set := radixtree.Set{}
+			err := set.UnmarshalBinary(data)
+			assert.Error(t, err, name)
+			assert.True(t, errors.Is(err, radixtree.ErrInvalidEncoding) || errors.Is(err, io.ErrUnexpectedEOF), name)
+
+			_, err = set.ReadFrom(bytes.NewReader(data))
+			assert.Error(t, err, name)
+		}
+
+		set := radixtree.Set{}
+		assert.Equal(t, radixtree.ErrInvalidEncoding, set.UnmarshalBinary(append(withLength(1, 1, 'a', 1, 0), 0)))
+		assert.NoError(t, set.UnmarshalBinary(withLength(1, 0, 0, 2, 1, 'a', 1, 0, 1, 'b', 1, 0)))
+		assert.Equal(t, []string{"a", "b"}, slices.Collect(set.All()))
+	})
+}
+
+func BenchmarkSetUnmarshalBinary(b *testing.B) {
+	random := rand.New(rand.NewSource(42))
+	words := make([]string, 300000)
+	for i := range words {
+		words[i] = strconv.FormatInt(random.Int63(), 36)
+	}
+
+	set := radixtree.Set{}
+	for _, word := range words {
+		set.Add(word)
+	}
+	encoded, err := set.MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("decode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			decoded := radixtree.Set{}
+			if err := decoded.UnmarshalBinary(encoded); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("read", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			decoded := radixtree.Set{}
+			if _, err := decoded.ReadFrom(bytes.NewReader(encoded)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("add", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			added := radixtree.Set{}
+			for _, word := range words {
+				added.Add(word)
+			}
+		}
+	})
+}
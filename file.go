@@ -0,0 +1,215 @@
+package radixtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// The file format of Save and Load is made of a header followed by sections,
+// with every integer in big endian:
+//
+//	header:  magic "RDXT" | major version (1 byte) | minor version (1 byte) |
+//	         kind ('M' for maps, 'S' for sets) | CRC-32C of the previous bytes (4 bytes)
+//	section: type (1 byte) | payload length (8 bytes) |
+//	         CRC-32C of the type and length (4 bytes) |
+//	         payload | CRC-32C of the payload (4 bytes)
+//
+// The header of each section has its own checksum, so that a corrupted length
+// is caught before it is used to read the payload.
+//
+// The sections are
+//
+//	0: end of the file, with no payload
+//	1: tree, whose payload is the number of words (uvarint) followed by
+//	   the encoding of WriteTo
+//
+// Readers reject files with a major version they don't know, and skip the
+// sections they don't know, so later minor versions can add sections which
+// older readers safely ignore.
+
+const (
+	fileMagic        = "RDXT"
+	fileMajorVersion = 1
+	fileMinorVersion = 0
+	fileHeaderSize   = len(fileMagic) + 3 + 4
+
+	sectionHeaderSize = 1 + 8 + 4
+
+	fileKindMap = 'M'
+	fileKindSet = 'S'
+
+	sectionEnd  = 0
+	sectionTree = 1
+)
+
+var (
+	ErrNotRadixFile = errors.New("radixtree: not a radix tree file")
+	ErrTruncated    = errors.New("radixtree: file is truncated")
+	ErrKindMismatch = errors.New("radixtree: file holds a map where a set is expected, or the opposite")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type VersionError struct {
+	Major, Minor uint8
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("radixtree: unsupported file version %d.%d", e.Major, e.Minor)
+}
+
+// ChecksumError reports a region of the file whose contents
+// don't match their checksum.
+type ChecksumError struct {
+	Section string
+	Offset  int64
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("radixtree: checksum mismatch in %s at offset %d", e.Section, e.Offset)
+}
+
+func writeFile[V any](w io.Writer, kind byte, root *radixNode[V], encodeValue func(V) ([]byte, error)) error {
+	header := []byte(fileMagic)
+	header = append(header, fileMajorVersion, fileMinorVersion, kind)
+	header = binary.BigEndian.AppendUint32(header, crc32.Checksum(header, castagnoli))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	var tree bytes.Buffer
+	var count int64
+	if root != nil {
+		count = root.count
+	}
+	tree.Write(binary.AppendUvarint(nil, uint64(count)))
+	if err := encodeTree(&tree, root, encodeValue); err != nil {
+		return err
+	}
+
+	if err := writeSection(w, sectionTree, tree.Bytes()); err != nil {
+		return err
+	}
+	return writeSection(w, sectionEnd, nil)
+}
+
+func writeSection(w io.Writer, sectionType byte, payload []byte) error {
+	header := binary.BigEndian.AppendUint64([]byte{sectionType}, uint64(len(payload)))
+	header = binary.BigEndian.AppendUint32(header, crc32.Checksum(header, castagnoli))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	_, err := w.Write(binary.BigEndian.AppendUint32(nil, crc32.Checksum(payload, castagnoli)))
+	return err
+}
+
+// readFileBytes is readFull reporting ErrTruncated when the file ends too early
+func readFileBytes(r io.Reader, n uint64) ([]byte, error) {
+	b, err := readFull(r, n)
+	if err == io.ErrUnexpectedEOF {
+		err = ErrTruncated
+	}
+	return b, err
+}
+
+// readFile reads a file written by writeFile, whose nodes
+// are owned by the generation gen
+func readFile[V any](r io.Reader, kind byte, gen uint64, decodeValue func([]byte) (V, error)) (*radixNode[V], error) {
+	header, err := readFileBytes(r, uint64(fileHeaderSize))
+	if err != nil {
+		return nil, err
+	}
+	if string(header[:len(fileMagic)]) != fileMagic {
+		return nil, ErrNotRadixFile
+	}
+	checksumAt := fileHeaderSize - 4
+	if crc32.Checksum(header[:checksumAt], castagnoli) != binary.BigEndian.Uint32(header[checksumAt:]) {
+		return nil, &ChecksumError{Section: "header", Offset: 0}
+	}
+	major, minor := header[len(fileMagic)], header[len(fileMagic)+1]
+	if major != fileMajorVersion {
+		return nil, &VersionError{Major: major, Minor: minor}
+	}
+	if header[len(fileMagic)+2] != kind {
+		return nil, ErrKindMismatch
+	}
+
+	var root *radixNode[V]
+	foundTree := false
+	offset := int64(fileHeaderSize)
+	for {
+		sectionHeader, err := readFileBytes(r, sectionHeaderSize)
+		if err != nil {
+			return nil, err
+		}
+		checksumAt := sectionHeaderSize - 4
+		if crc32.Checksum(sectionHeader[:checksumAt], castagnoli) != binary.BigEndian.Uint32(sectionHeader[checksumAt:]) {
+			return nil, &ChecksumError{Section: "section header", Offset: offset}
+		}
+		sectionType := sectionHeader[0]
+		length := binary.BigEndian.Uint64(sectionHeader[1:])
+		if length > math.MaxInt64-sectionHeaderSize-4 {
+			return nil, fmt.Errorf("radixtree: section too long: %w", ErrInvalidEncoding)
+		}
+
+		payload, err := readFileBytes(r, length)
+		if err != nil {
+			return nil, err
+		}
+		checksum, err := readFileBytes(r, 4)
+		if err != nil {
+			return nil, err
+		}
+		if crc32.Checksum(payload, castagnoli) != binary.BigEndian.Uint32(checksum) {
+			return nil, &ChecksumError{Section: fmt.Sprintf("section %d", sectionType), Offset: offset + sectionHeaderSize}
+		}
+
+		switch sectionType {
+		case sectionEnd:
+			if !foundTree {
+				return nil, fmt.Errorf("radixtree: file has no tree: %w", ErrInvalidEncoding)
+			}
+			return root, nil
+		case sectionTree:
+			if foundTree {
+				return nil, fmt.Errorf("radixtree: file has two trees: %w", ErrInvalidEncoding)
+			}
+			foundTree = true
+			if root, err = readTreeSection(payload, gen, decodeValue); err != nil {
+				return nil, err
+			}
+		}
+
+		offset += sectionHeaderSize + int64(length) + 4
+	}
+}
+
+func readTreeSection[V any](payload []byte, gen uint64, decodeValue func([]byte) (V, error)) (*radixNode[V], error) {
+	count, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return nil, ErrInvalidEncoding
+	}
+
+	root, err := decodeTree(payload[n:], gen, decodeValue)
+	if err != nil {
+		return nil, err
+	}
+
+	var actual int64
+	if root != nil {
+		actual = root.count
+	}
+	if uint64(actual) != count {
+		return nil, ErrInvalidEncoding
+	}
+	return root, nil
+}
@@ -0,0 +1,157 @@
+package radixtree_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+func isFileError(err error) bool {
+	var versionError *radixtree.VersionError
+	var checksumError *radixtree.ChecksumError
+	return errors.Is(err, radixtree.ErrNotRadixFile) ||
+		errors.Is(err, radixtree.ErrTruncated) ||
+		errors.Is(err, radixtree.ErrKindMismatch) ||
+		errors.As(err, &versionError) ||
+		errors.As(err, &checksumError)
+}
+
+func savedSet(t *testing.T) (*radixtree.Set, []byte) {
+	set := &radixtree.Set{}
+	for i := 0; i < 100; i++ {
+		set.Add("word/" + strconv.Itoa(i*7))
+	}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, set.Save(&buffer))
+	return set, buffer.Bytes()
+}
+
+// withHeader changes the header of a saved file, keeping its checksum valid
+func withHeader(file []byte, major, minor byte) []byte {
+	changed := slices.Clone(file)
+	changed[4], changed[5] = major, minor
+	checksum := crc32.Checksum(changed[:7], crc32.MakeTable(crc32.Castagnoli))
+	binary.BigEndian.PutUint32(changed[7:11], checksum)
+	return changed
+}
+
+func TestFileFormat(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		set, file := savedSet(t)
+
+		loaded := radixtree.Set{}
+		assert.NoError(t, loaded.Load(bytes.NewReader(file)))
+		assert.Equal(t, slices.Collect(set.All()), slices.Collect(loaded.All()))
+
+		rmap := radixtree.Map[string]{}
+		rmap.Add("romane", "1")
+		rmap.Add("", "empty")
+		var buffer bytes.Buffer
+		assert.NoError(t, rmap.Save(&buffer))
+
+		loadedMap := radixtree.Map[string]{}
+		assert.NoError(t, loadedMap.Load(&buffer))
+		assert.Equal(t, maps.Collect(rmap.All()), maps.Collect(loadedMap.All()))
+
+		empty := radixtree.Set{}
+		buffer.Reset()
+		assert.NoError(t, empty.Save(&buffer))
+		assert.NoError(t, loaded.Load(&buffer))
+		assert.EqualValues(t, 0, loaded.Size())
+	})
+
+	t.Run("truncated files", func(t *testing.T) {
+		_, file := savedSet(t)
+
+		for i := 0; i < len(file); i++ {
+			loaded := radixtree.Set{}
+			loaded.Add("kept")
+			err := loaded.Load(bytes.NewReader(file[:i]))
+			assert.Equal(t, radixtree.ErrTruncated, err, "length %d", i)
+			assert.Equal(t, []string{"kept"}, slices.Collect(loaded.All()))
+		}
+	})
+
+	t.Run("flipped bits", func(t *testing.T) {
+		_, file := savedSet(t)
+
+		for i := 0; i < len(file); i++ {
+			for bit := 0; bit < 8; bit++ {
+				corrupted := slices.Clone(file)
+				corrupted[i] ^= 1 << bit
+
+				loaded := radixtree.Set{}
+				err := loaded.Load(bytes.NewReader(corrupted))
+				assert.True(t, isFileError(err), "byte %d, bit %d: %v", i, bit, err)
+			}
+		}
+	})
+
+	t.Run("corrupted section lengths", func(t *testing.T) {
+		_, file := savedSet(t)
+
+		// the length of the tree section follows the header and the section type
+		for i := 12; i < 20; i++ {
+			for bit := 0; bit < 8; bit++ {
+				corrupted := slices.Clone(file)
+				corrupted[i] ^= 1 << bit
+
+				loaded := radixtree.Set{}
+				err := loaded.Load(bytes.NewReader(corrupted))
+				var checksumError *radixtree.ChecksumError
+				assert.True(t, errors.As(err, &checksumError), "byte %d, bit %d: %v", i, bit, err)
+			}
+		}
+	})
+
+	t.Run("not a radix tree file", func(t *testing.T) {
+		loaded := radixtree.Set{}
+		assert.Equal(t, radixtree.ErrNotRadixFile, loaded.Load(bytes.NewReader([]byte("word/0\nword/7\n"))))
+	})
+
+	t.Run("kind mismatch", func(t *testing.T) {
+		_, file := savedSet(t)
+
+		loaded := radixtree.Map[int]{}
+		assert.Equal(t, radixtree.ErrKindMismatch, loaded.Load(bytes.NewReader(file)))
+	})
+
+	t.Run("later major versions are rejected", func(t *testing.T) {
+		_, file := savedSet(t)
+
+		loaded := radixtree.Set{}
+		err := loaded.Load(bytes.NewReader(withHeader(file, 2, 0)))
+		var versionError *radixtree.VersionError
+		assert.True(t, errors.As(err, &versionError))
+		assert.EqualValues(t, 2, versionError.Major)
+	})
+
+	t.Run("later minor versions skip unknown sections", func(t *testing.T) {
+		set, file := savedSet(t)
+
+		// a section of an unknown type goes before the tree
+		table := crc32.MakeTable(crc32.Castagnoli)
+		unknown := []byte{7, 0, 0, 0, 0, 0, 0, 0, 3}
+		unknown = binary.BigEndian.AppendUint32(unknown, crc32.Checksum(unknown, table))
+		unknown = append(unknown, 'n', 'e', 'w')
+		unknown = binary.BigEndian.AppendUint32(unknown, crc32.Checksum([]byte("new"), table))
+
+		changed := withHeader(file, 1, 3)
+		changed = append(changed[:11:11], append(unknown, changed[11:]...)...)
+
+		loaded := radixtree.Set{}
+		assert.NoError(t, loaded.Load(bytes.NewReader(changed)))
+		assert.Equal(t, slices.Collect(set.All()), slices.Collect(loaded.All()))
+	})
+}
@@ -0,0 +1,554 @@
+package radixtree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"iter"
+	"sort"
+)
+
+// An image is a flat encoding of a tree which can be searched in place,
+// without building its nodes, so it can be memory-mapped. Its layout is
+//
+//	header:  magic "RDXI" | major version (1 byte) | minor version (1 byte) |
+//	         kind ('M' for maps, 'S' for sets) | 0
+//	nodes
+//	trailer: offset of the root, or 0 if the tree is empty (8 bytes) |
+//	         number of words (8 bytes)
+//
+// where each node is
+//
+//	final (byte) | part length (uvarint) | part |
+//	value length (uvarint) | value (only in final nodes of maps) |
+//	child count (uvarint) | first byte of each child, in increasing order |
+//	offset of each child (8 bytes each)
+//
+// with every integer in little endian. Children are written before their
+// parents, so the offsets of the children are always smaller. The whole
+// node graph is checked when an image is opened, so that searches can
+// trust it afterwards.
+
+const (
+	imageMagic        = "RDXI"
+	imageMajorVersion = 1
+	imageMinorVersion = 0
+	imageHeaderSize   = 8
+	imageTrailerSize  = 16
+)
+
+type imageWriter struct {
+	w       *bufio.Writer
+	offset  uint64
+	scratch []byte
+}
+
+func (iw *imageWriter) write(b []byte) error {
+	n, err := iw.w.Write(b)
+	iw.offset += uint64(n)
+	return err
+}
+
+// writeImage writes the image of the tree to w.
+// Values are left out when encodeValue is nil.
+func writeImage[V any](w io.Writer, kind byte, root *radixNode[V], encodeValue func(V) ([]byte, error)) error {
+	iw := &imageWriter{w: bufio.NewWriter(w)}
+
+	header := append([]byte(imageMagic), imageMajorVersion, imageMinorVersion, kind, 0)
+	if err := iw.write(header); err != nil {
+		return err
+	}
+
+	var rootOffset, count uint64
+	if root != nil {
+		var err error
+		if rootOffset, err = writeImageNode(iw, root, encodeValue); err != nil {
+			return err
+		}
+		count = uint64(root.count)
+	}
+
+	trailer := binary.LittleEndian.AppendUint64(nil, rootOffset)
+	trailer = binary.LittleEndian.AppendUint64(trailer, count)
+	if err := iw.write(trailer); err != nil {
+		return err
+	}
+	return iw.w.Flush()
+}
+
+// writeImageNode writes the subtree of node, returning the offset of node
+func writeImageNode[V any](iw *imageWriter, node *radixNode[V], encodeValue func(V) ([]byte, error)) (uint64, error) {
+	offsets := make([]uint64, len(node.children))
+	for i, child := range node.children {
+		offset, err := writeImageNode(iw, child, encodeValue)
+		if err != nil {
+			return 0, err
+		}
+		offsets[i] = offset
+	}
+
+	b := append(iw.scratch[:0], boolByte(node.final))
+	b = binary.AppendUvarint(b, uint64(len(node.part)))
+	b = append(b, node.part...)
+	if node.final && encodeValue != nil {
+		value, err := encodeValue(node.data)
+		if err != nil {
+			return 0, err
+		}
+		b = binary.AppendUvarint(b, uint64(len(value)))
+		b = append(b, value...)
+	}
+	b = binary.AppendUvarint(b, uint64(len(node.children)))
+	for _, child := range node.children {
+		b = append(b, child.part[0])
+	}
+	for _, offset := range offsets {
+		b = binary.LittleEndian.AppendUint64(b, offset)
+	}
+	iw.scratch = b
+
+	offset := iw.offset
+	return offset, iw.write(b)
+}
+
+type image struct {
+	// the bytes of the header and nodes, without the trailer
+	data       []byte
+	withValues bool
+	rootOffset uint64
+	size       int64
+}
+
+func newImage(data []byte, kind byte) (*image, error) {
+	if len(data) < len(imageMagic) || string(data[:len(imageMagic)]) != imageMagic {
+		return nil, ErrNotRadixFile
+	}
+	if len(data) < imageHeaderSize+imageTrailerSize {
+		return nil, ErrTruncated
+	}
+	major, minor := data[len(imageMagic)], data[len(imageMagic)+1]
+	if major != imageMajorVersion {
+		return nil, &VersionError{Major: major, Minor: minor}
+	}
+	if data[len(imageMagic)+2] != kind {
+		return nil, ErrKindMismatch
+	}
+
+	trailer := data[len(data)-imageTrailerSize:]
+	img := &image{
+		data:       data[:len(data)-imageTrailerSize],
+		withValues: kind == fileKindMap,
+		rootOffset: binary.LittleEndian.Uint64(trailer),
+		size:       int64(binary.LittleEndian.Uint64(trailer[8:])),
+	}
+	if err := img.validate(); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// validate checks that the nodes form a tree, which holds as many words as
+// the trailer says, with each child reached through the first byte of its part
+func (img *image) validate() error {
+	if img.rootOffset == 0 {
+		if img.size != 0 {
+			return ErrInvalidEncoding
+		}
+		return nil
+	}
+
+	root, ok := img.node(img.rootOffset)
+	if !ok {
+		return ErrInvalidEncoding
+	}
+	// every node takes more than a byte, so visiting more nodes than there
+	// are bytes means that some are shared, which could take forever
+	var visited, words int64
+	pending := []imageNode{root}
+	for len(pending) > 0 {
+		node := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		visited++
+		if visited > int64(len(img.data)) {
+			return ErrInvalidEncoding
+		}
+		if node.final {
+			words++
+		} else if len(node.firstBytes) == 0 {
+			return ErrInvalidEncoding
+		}
+
+		for i, b := range node.firstBytes {
+			if i > 0 && node.firstBytes[i-1] >= b {
+				return ErrInvalidEncoding
+			}
+			offset := binary.LittleEndian.Uint64(node.offsets[i*8:])
+			if offset >= node.offset {
+				return ErrInvalidEncoding
+			}
+			child, ok := img.node(offset)
+			if !ok || len(child.part) == 0 || child.part[0] != b {
+				return ErrInvalidEncoding
+			}
+			pending = append(pending, child)
+		}
+	}
+	if words != img.size {
+		return ErrInvalidEncoding
+	}
+	return nil
+}
+
+// imageNode is a node of an image, whose fields point into the image
+type imageNode struct {
+	offset     uint64
+	final      bool
+	part       []byte
+	value      []byte
+	firstBytes []byte
+	offsets    []byte
+}
+
+// node decodes the node at offset, returning false if it lies outside
+// of the image or is malformed
+func (img *image) node(offset uint64) (imageNode, bool) {
+	if offset < imageHeaderSize || offset >= uint64(len(img.data)) {
+		return imageNode{}, false
+	}
+	b := img.data[offset:]
+	node := imageNode{offset: offset, final: b[0] == 1}
+	b = b[1:]
+
+	var ok bool
+	if node.part, b, ok = readImageBytes(b); !ok {
+		return imageNode{}, false
+	}
+	if node.final && img.withValues {
+		if node.value, b, ok = readImageBytes(b); !ok {
+			return imageNode{}, false
+		}
+	}
+
+	childCount, n := binary.Uvarint(b)
+	if n <= 0 || childCount > 256 || uint64(len(b)-n) < childCount*9 {
+		return imageNode{}, false
+	}
+	b = b[n:]
+	node.firstBytes = b[:childCount]
+	node.offsets = b[childCount : childCount*9]
+	return node, true
+}
+
+func readImageBytes(b []byte) ([]byte, []byte, bool) {
+	length, n := binary.Uvarint(b)
+	if n <= 0 || uint64(len(b)-n) < length {
+		return nil, nil, false
+	}
+	return b[n : n+int(length)], b[n+int(length):], true
+}
+
+func (img *image) root() (imageNode, bool) {
+	if img.rootOffset == 0 {
+		return imageNode{}, false
+	}
+	return img.node(img.rootOffset)
+}
+
+// childAt returns the i-th child of node, which validate made sure exists
+func (img *image) childAt(node imageNode, i int) imageNode {
+	child, _ := img.node(binary.LittleEndian.Uint64(node.offsets[i*8:]))
+	return child
+}
+
+func (img *image) child(node imageNode, b byte) (imageNode, bool) {
+	i := sort.Search(len(node.firstBytes), func(i int) bool {
+		return node.firstBytes[i] >= b
+	})
+	if i == len(node.firstBytes) || node.firstBytes[i] != b {
+		return imageNode{}, false
+	}
+	return img.childAt(node, i), true
+}
+
+func (img *image) get(str string) (imageNode, bool) {
+	node, ok := img.root()
+	for ok {
+		if len(str) < len(node.part) || string(node.part) != str[:len(node.part)] {
+			return imageNode{}, false
+		}
+		str = str[len(node.part):]
+		if len(str) == 0 {
+			return node, node.final
+		}
+		node, ok = img.child(node, str[0])
+	}
+	return imageNode{}, false
+}
+
+// longestPrefix returns the node of the longest word which is a prefix
+// of str, and the length of that word
+func (img *image) longestPrefix(str string) (imageNode, int, bool) {
+	var found imageNode
+	var foundLength int
+	exists := false
+
+	length := 0
+	node, ok := img.root()
+	for ok {
+		rest := str[length:]
+		if len(rest) < len(node.part) || string(node.part) != rest[:len(node.part)] {
+			break
+		}
+		length += len(node.part)
+		if node.final {
+			found, foundLength, exists = node, length, true
+		}
+		if length == len(str) {
+			break
+		}
+		node, ok = img.child(node, str[length])
+	}
+	return found, foundLength, exists
+}
+
+// withPrefix returns the topmost node whose words start with prefix,
+// and the key of the words above it
+func (img *image) withPrefix(prefix string) (imageNode, []byte, bool) {
+	var key []byte
+	node, ok := img.root()
+	for ok {
+		if len(prefix) <= len(node.part) {
+			return node, key, string(node.part[:len(prefix)]) == prefix
+		}
+		if string(node.part) != prefix[:len(node.part)] {
+			return imageNode{}, nil, false
+		}
+		key = append(key, node.part...)
+		prefix = prefix[len(node.part):]
+		node, ok = img.child(node, prefix[0])
+	}
+	return imageNode{}, nil, false
+}
+
+func (img *image) traverseWithPrefix(prefix string, action func(string, []byte) bool) {
+	node, key, ok := img.withPrefix(prefix)
+	if ok {
+		img.traverseRecursive(node, key, action)
+	}
+}
+
+func (img *image) traverseRecursive(node imageNode, key []byte, action func(string, []byte) bool) bool {
+	key = append(key, node.part...)
+	if node.final && !action(string(key), node.value) {
+		return false
+	}
+	for i := range node.firstBytes {
+		if !img.traverseRecursive(img.childAt(node, i), key, action) {
+			return false
+		}
+	}
+	return true
+}
+
+// MappedMap is a read-only map searched directly on its image, which
+// Map.WriteImage writes. Its values are decoded by the codec of the written
+// map on every access, and the methods ending in Bytes skip decoding them,
+// returning the encoded bytes which point into the image, so they must be
+// copied to outlive it.
+type MappedMap[V any] struct {
+	img   *image
+	codec ValueCodec[V]
+	close func() error
+}
+
+// NewMappedMap searches the image in data, which must not change while
+// the map is in use. The values are decoded by codec, or by GobCodec if
+// it is nil.
+func NewMappedMap[V any](data []byte, codec ValueCodec[V]) (*MappedMap[V], error) {
+	img, err := newImage(data, fileKindMap)
+	if err != nil {
+		return nil, err
+	}
+	if codec == nil {
+		codec = GobCodec[V]{}
+	}
+	return &MappedMap[V]{img: img, codec: codec, close: func() error { return nil }}, nil
+}
+
+// OpenMappedMap maps the image in the file at path into memory,
+// which is released by Close.
+func OpenMappedMap[V any](path string, codec ValueCodec[V]) (*MappedMap[V], error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mm, err := NewMappedMap(data, codec)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	mm.close = unmap
+	return mm, nil
+}
+
+// Close releases the image, after which neither the map nor
+// the bytes it returned can be used.
+func (mm *MappedMap[V]) Close() error {
+	return mm.close()
+}
+
+func (mm *MappedMap[V]) Get(str string) (V, bool, error) {
+	node, found := mm.img.get(str)
+	if !found {
+		var zero V
+		return zero, false, nil
+	}
+	data, err := mm.codec.DecodeValue(node.value)
+	return data, err == nil, err
+}
+
+func (mm *MappedMap[V]) GetBytes(str string) ([]byte, bool) {
+	node, found := mm.img.get(str)
+	return node.value, found
+}
+
+func (mm *MappedMap[V]) Size() int64 {
+	return mm.img.size
+}
+
+func (mm *MappedMap[V]) LongestPrefix(str string) (string, V, bool, error) {
+	node, length, found := mm.img.longestPrefix(str)
+	if !found {
+		var zero V
+		return "", zero, false, nil
+	}
+	data, err := mm.codec.DecodeValue(node.value)
+	return str[:length], data, err == nil, err
+}
+
+// ForEach stops at the first value which fails to decode, returning the error.
+func (mm *MappedMap[V]) ForEach(action func(string, V)) error {
+	return mm.ForEachWithPrefix("", action)
+}
+
+// ForEachWithPrefix stops at the first value which fails to decode,
+// returning the error.
+func (mm *MappedMap[V]) ForEachWithPrefix(prefix string, action func(string, V)) error {
+	var err error
+	mm.img.traverseWithPrefix(prefix, func(key string, encoded []byte) bool {
+		var data V
+		if data, err = mm.codec.DecodeValue(encoded); err != nil {
+			return false
+		}
+		action(key, data)
+		return true
+	})
+	return err
+}
+
+// All stops at the first value which fails to decode, storing the error
+// in err, which is left untouched otherwise.
+func (mm *MappedMap[V]) All(err *error) iter.Seq2[string, V] {
+	return mm.WithPrefix("", err)
+}
+
+// WithPrefix stops at the first value which fails to decode, storing the
+// error in err, which is left untouched otherwise.
+func (mm *MappedMap[V]) WithPrefix(prefix string, err *error) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		mm.img.traverseWithPrefix(prefix, func(key string, encoded []byte) bool {
+			data, decodeErr := mm.codec.DecodeValue(encoded)
+			if decodeErr != nil {
+				*err = decodeErr
+				return false
+			}
+			return yield(key, data)
+		})
+	}
+}
+
+func (mm *MappedMap[V]) AllBytes() iter.Seq2[string, []byte] {
+	return mm.WithPrefixBytes("")
+}
+
+func (mm *MappedMap[V]) WithPrefixBytes(prefix string) iter.Seq2[string, []byte] {
+	return func(yield func(string, []byte) bool) {
+		mm.img.traverseWithPrefix(prefix, yield)
+	}
+}
+
+// MappedSet is a read-only set searched directly on its image,
+// which Set.WriteImage writes.
+type MappedSet struct {
+	img   *image
+	close func() error
+}
+
+// NewMappedSet searches the image in data, which must not change while
+// the set is in use.
+func NewMappedSet(data []byte) (*MappedSet, error) {
+	img, err := newImage(data, fileKindSet)
+	if err != nil {
+		return nil, err
+	}
+	return &MappedSet{img: img, close: func() error { return nil }}, nil
+}
+
+// OpenMappedSet maps the image in the file at path into memory,
+// which is released by Close.
+func OpenMappedSet(path string) (*MappedSet, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := NewMappedSet(data)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	ms.close = unmap
+	return ms, nil
+}
+
+// Close releases the image, after which the set can't be used.
+func (ms *MappedSet) Close() error {
+	return ms.close()
+}
+
+func (ms *MappedSet) Contains(str string) bool {
+	_, found := ms.img.get(str)
+	return found
+}
+
+func (ms *MappedSet) Size() int64 {
+	return ms.img.size
+}
+
+func (ms *MappedSet) LongestPrefix(str string) (string, bool) {
+	_, length, found := ms.img.longestPrefix(str)
+	return str[:length], found
+}
+
+func (ms *MappedSet) ForEach(action func(string)) {
+	ms.ForEachWithPrefix("", action)
+}
+
+func (ms *MappedSet) ForEachWithPrefix(prefix string, action func(string)) {
+	ms.img.traverseWithPrefix(prefix, func(word string, _ []byte) bool {
+		action(word)
+		return true
+	})
+}
+
+func (ms *MappedSet) All() iter.Seq[string] {
+	return ms.WithPrefix("")
+}
+
+func (ms *MappedSet) WithPrefix(prefix string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		ms.img.traverseWithPrefix(prefix, func(word string, _ []byte) bool {
+			return yield(word)
+		})
+	}
+}
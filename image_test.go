@@ -0,0 +1,214 @@
+package radixtree_test
+
+import (
+	"bytes"
+	"maps"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeImageFile(t *testing.T, write func(*os.File) error) string {
+	path := filepath.Join(t.TempDir(), "image")
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	assert.NoError(t, write(file))
+	assert.NoError(t, file.Close())
+	return path
+}
+
+func TestMappedMap(t *testing.T) {
+	t.Parallel()
+
+	random := rand.New(rand.NewSource(42))
+	rmap := radixtree.Map[int]{}
+	rmap.SetValueCodec(decimalCodec{})
+	for i := 0; i < 2000; i++ {
+		rmap.Put(strconv.FormatInt(int64(random.Intn(20000)), 5), i)
+	}
+	rmap.Put("", -1)
+
+	path := writeImageFile(t, func(file *os.File) error {
+		return rmap.WriteImage(file)
+	})
+	mapped, err := radixtree.OpenMappedMap[int](path, decimalCodec{})
+	assert.NoError(t, err)
+	defer mapped.Close()
+
+	t.Run("lookups", func(t *testing.T) {
+		assert.Equal(t, rmap.Size(), mapped.Size())
+
+		for i := 0; i < 1000; i++ {
+			key := strconv.FormatInt(int64(random.Intn(25000)), 5)
+
+			expected, expectedExists := rmap.Get(key)
+			data, exists, err := mapped.Get(key)
+			assert.NoError(t, err)
+			assert.Equal(t, expectedExists, exists, key)
+			assert.Equal(t, expected, data, key)
+
+			encoded, exists := mapped.GetBytes(key)
+			assert.Equal(t, expectedExists, exists, key)
+			if exists {
+				assert.Equal(t, strconv.Itoa(expected), string(encoded), key)
+			}
+
+			expectedPrefix, expected, _ := rmap.LongestPrefix(key + "9")
+			prefix, data, found, err := mapped.LongestPrefix(key + "9")
+			assert.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, expectedPrefix, prefix)
+			assert.Equal(t, expected, data)
+		}
+	})
+
+	t.Run("prefix iteration", func(t *testing.T) {
+		for _, prefix := range []string{"", "1", "12", "123", "1234", "4444", "9"} {
+			expected := maps.Collect(rmap.WithPrefix(prefix))
+
+			keys := []string{}
+			actual := map[string]int{}
+			assert.NoError(t, mapped.ForEachWithPrefix(prefix, func(key string, data int) {
+				keys = append(keys, key)
+				actual[key] = data
+			}))
+			assert.Equal(t, expected, actual, prefix)
+			assert.True(t, slices.IsSorted(keys))
+
+			var err error
+			assert.Equal(t, expected, maps.Collect(mapped.WithPrefix(prefix, &err)), prefix)
+			assert.NoError(t, err)
+
+			encoded := map[string]string{}
+			for key, value := range mapped.WithPrefixBytes(prefix) {
+				encoded[key] = string(value)
+			}
+			assert.Len(t, encoded, len(expected))
+		}
+	})
+
+	t.Run("default codec", func(t *testing.T) {
+		gobMap := radixtree.Map[[]string]{}
+		gobMap.Add("romane", []string{"a", "b"})
+
+		var buffer bytes.Buffer
+		assert.NoError(t, gobMap.WriteImage(&buffer))
+		mapped, err := radixtree.NewMappedMap[[]string](buffer.Bytes(), nil)
+		assert.NoError(t, err)
+
+		data, exists, err := mapped.Get("romane")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, []string{"a", "b"}, data)
+	})
+
+	t.Run("values which fail to decode", func(t *testing.T) {
+		var buffer bytes.Buffer
+		assert.NoError(t, rmap.WriteImage(&buffer))
+		mapped, err := radixtree.NewMappedMap[string](buffer.Bytes(), nil)
+		assert.NoError(t, err)
+
+		_, exists, err := mapped.Get("")
+		assert.Error(t, err)
+		assert.False(t, exists)
+		assert.Error(t, mapped.ForEach(func(string, string) {}))
+
+		var iterErr error
+		for range mapped.All(&iterErr) {
+		}
+		assert.Error(t, iterErr)
+	})
+}
+
+func TestMappedSet(t *testing.T) {
+	t.Parallel()
+
+	set := radixtree.Set{}
+	for _, word := range []string{"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus"} {
+		set.Add(word)
+	}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, set.WriteImage(&buffer))
+	image := buffer.Bytes()
+
+	t.Run("lookups", func(t *testing.T) {
+		path := writeImageFile(t, func(file *os.File) error {
+			return set.WriteImage(file)
+		})
+		mapped, err := radixtree.OpenMappedSet(path)
+		assert.NoError(t, err)
+		defer mapped.Close()
+
+		assert.EqualValues(t, 7, mapped.Size())
+		assert.True(t, mapped.Contains("romulus"))
+		assert.False(t, mapped.Contains("rom"))
+		assert.False(t, mapped.Contains("rubiconx"))
+
+		word, found := mapped.LongestPrefix("rubicundusness")
+		assert.True(t, found)
+		assert.Equal(t, "rubicundus", word)
+		_, found = mapped.LongestPrefix("rom")
+		assert.False(t, found)
+
+		assert.Equal(t, []string{"rubicon", "rubicundus"}, slices.Collect(mapped.WithPrefix("rubic")))
+		assert.Equal(t, slices.Collect(set.All()), slices.Collect(mapped.All()))
+	})
+
+	t.Run("empty set", func(t *testing.T) {
+		var buffer bytes.Buffer
+		assert.NoError(t, (&radixtree.Set{}).WriteImage(&buffer))
+
+		mapped, err := radixtree.NewMappedSet(buffer.Bytes())
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, mapped.Size())
+		assert.False(t, mapped.Contains(""))
+		assert.Empty(t, slices.Collect(mapped.All()))
+	})
+
+	t.Run("invalid images", func(t *testing.T) {
+		_, err := radixtree.NewMappedMap[int](image, nil)
+		assert.Equal(t, radixtree.ErrKindMismatch, err)
+
+		_, err = radixtree.NewMappedSet([]byte("romane\nromanus\n"))
+		assert.Equal(t, radixtree.ErrNotRadixFile, err)
+
+		_, err = radixtree.NewMappedSet(image[:10])
+		assert.Equal(t, radixtree.ErrTruncated, err)
+	})
+
+	t.Run("invalid child offsets", func(t *testing.T) {
+		// the root is the last node, so its last child offset
+		// comes right before the trailer
+		trailer := len(image) - 16
+		corrupted := slices.Clone(image)
+		copy(corrupted[trailer-8:trailer], corrupted[trailer:trailer+8])
+
+		_, err := radixtree.NewMappedSet(corrupted)
+		assert.Equal(t, radixtree.ErrInvalidEncoding, err)
+	})
+
+	t.Run("corrupted images never panic", func(t *testing.T) {
+		for i := 0; i < len(image); i++ {
+			for bit := 0; bit < 8; bit++ {
+				corrupted := slices.Clone(image)
+				corrupted[i] ^= 1 << bit
+
+				mapped, err := radixtree.NewMappedSet(corrupted)
+				if err != nil {
+					continue
+				}
+				mapped.Contains("romanus")
+				mapped.LongestPrefix("rubicundus")
+				// images which open are whole trees
+				assert.EqualValues(t, mapped.Size(), len(slices.Collect(mapped.All())))
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package radixtree
+
+import "iter"
+
+// ImmutableMap is a persistent version of Map. Its changes return a new map,
+// which shares all the nodes they don't touch with the old one, so keeping
+// many versions around costs only the paths changed between them.
+// The zero value is an empty map.
+type ImmutableMap[V any] struct {
+	m Map[V]
+}
+
+// change applies the given change to a copy of the map, which only copies
+// the nodes the change touches
+func (im ImmutableMap[V]) change(change func(next *Map[V])) ImmutableMap[V] {
+	next := im.m
+	next.gen = newGeneration()
+	change(&next)
+	return ImmutableMap[V]{m: next}
+}
+
+func (im ImmutableMap[V]) With(str string, data V) ImmutableMap[V] {
+	return im.change(func(next *Map[V]) {
+		next.Put(str, data)
+	})
+}
+
+func (im ImmutableMap[V]) Without(str string) ImmutableMap[V] {
+	if _, exists := im.Get(str); !exists {
+		return im
+	}
+	return im.change(func(next *Map[V]) {
+		next.Remove(str)
+	})
+}
+
+func (im ImmutableMap[V]) Get(str string) (V, bool) {
+	return im.m.Get(str)
+}
+
+func (im ImmutableMap[V]) Size() int64 {
+	return im.m.Size()
+}
+
+func (im ImmutableMap[V]) ForEach(action func(string, V)) {
+	im.m.ForEach(action)
+}
+
+func (im ImmutableMap[V]) ForEachWithPrefix(prefix string, action func(string, V)) {
+	im.m.ForEachWithPrefix(prefix, action)
+}
+
+func (im ImmutableMap[V]) All() iter.Seq2[string, V] {
+	return im.m.All()
+}
+
+func (im ImmutableMap[V]) Iterator() *Iterator[V] {
+	return im.m.Iterator()
+}
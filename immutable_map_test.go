@@ -0,0 +1,79 @@
+package radixtree_test
+
+import (
+	"fmt"
+	"maps"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImmutableMap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("behaves like a map", func(t *testing.T) {
+		empty := radixtree.ImmutableMap[int]{}
+		assert.EqualValues(t, 0, empty.Size())
+
+		m := empty.With("bliss", 1).With("blissful", 2).With("blister", 3).With("blissful", 4)
+
+		data, exists := m.Get("blissful")
+		assert.True(t, exists)
+		assert.EqualValues(t, 4, data)
+		assert.EqualValues(t, 3, m.Size())
+
+		keys := []string{}
+		m.ForEachWithPrefix("bliss", func(s string, _ int) {
+			keys = append(keys, s)
+		})
+		assert.Equal(t, []string{"bliss", "blissful"}, keys)
+
+		m = m.Without("bliss").Without("missing")
+		_, exists = m.Get("bliss")
+		assert.False(t, exists)
+		assert.EqualValues(t, 2, m.Size())
+	})
+
+	t.Run("changes leave the old versions intact", func(t *testing.T) {
+		v1 := radixtree.ImmutableMap[string]{}.With("romane", "1").With("romanus", "1")
+		v2 := v1.With("romulus", "2").With("roman", "2")
+		v3 := v2.Without("romane").Without("romanus").With("romane", "3")
+
+		assert.Equal(t, map[string]string{"romane": "1", "romanus": "1"}, maps.Collect(v1.All()))
+		assert.Equal(t, map[string]string{"romane": "1", "romanus": "1", "romulus": "2", "roman": "2"}, maps.Collect(v2.All()))
+		assert.Equal(t, map[string]string{"romane": "3", "romulus": "2", "roman": "2"}, maps.Collect(v3.All()))
+	})
+
+	t.Run("thorough", func(t *testing.T) {
+		random := rand.New(rand.NewSource(42))
+
+		versions := []radixtree.ImmutableMap[int]{{}}
+		models := []map[string]int{{}}
+
+		for i := 0; i < 1000; i++ {
+			base := random.Intn(len(versions))
+			key := strconv.FormatInt(int64(random.Intn(200)), 3)
+
+			model := maps.Clone(models[base])
+			var version radixtree.ImmutableMap[int]
+			if random.Intn(3) == 0 {
+				version = versions[base].Without(key)
+				delete(model, key)
+			} else {
+				version = versions[base].With(key, i)
+				model[key] = i
+			}
+
+			versions = append(versions, version)
+			models = append(models, model)
+		}
+
+		for i, version := range versions {
+			assert.Equal(t, models[i], maps.Collect(version.All()), fmt.Sprintf("version %d", i))
+			assert.EqualValues(t, len(models[i]), version.Size())
+		}
+	})
+}
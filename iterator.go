@@ -0,0 +1,261 @@
+package radixtree
+
+type iteratorFrame[V any] struct {
+	node *radixNode[V]
+	// position of node among the children of its parent
+	index int
+	// length of the key before the part of node was appended to it
+	lenBefore int
+}
+
+// Iterator walks over the words of a Map in lexicographical order, in
+// either direction. It is positioned with First, Last or Seek, and it
+// must not be used anymore once the Map is modified.
+type Iterator[V any] struct {
+	root  *radixNode[V]
+	stack []iteratorFrame[V]
+	key   []byte
+}
+
+func newIterator[V any](root *radixNode[V]) *Iterator[V] {
+	return &Iterator[V]{root: root}
+}
+
+func (it *Iterator[V]) Valid() bool {
+	return len(it.stack) > 0
+}
+
+func (it *Iterator[V]) Key() string {
+	return string(it.key)
+}
+
+func (it *Iterator[V]) Value() V {
+	if !it.Valid() {
+		var zero V
+		return zero
+	}
+	return it.top().node.data
+}
+
+func (it *Iterator[V]) First() bool {
+	if !it.reset() {
+		return false
+	}
+
+	it.seekFirstInSubtree()
+	return it.Valid()
+}
+
+func (it *Iterator[V]) Last() bool {
+	if !it.reset() {
+		return false
+	}
+
+	it.seekLastInSubtree()
+	return it.Valid()
+}
+
+// Seek moves the iterator to the smallest word greater than or equal to str.
+func (it *Iterator[V]) Seek(str string) bool {
+	if !it.reset() {
+		return false
+	}
+
+	for {
+		frame := it.top()
+		endStr := str[frame.lenBefore:]
+
+		lenPrefix := commonPrefixLength(frame.node.part, endStr)
+
+		nodeIsPrefixOfString := lenPrefix == len(frame.node.part)
+		if !nodeIsPrefixOfString {
+			subtreeIsGreater := lenPrefix == len(endStr) || frame.node.part[lenPrefix] > endStr[lenPrefix]
+			if subtreeIsGreater {
+				it.seekFirstInSubtree()
+			} else {
+				it.advancePastSubtree()
+			}
+			return it.Valid()
+		}
+
+		endStr = endStr[lenPrefix:]
+		if len(endStr) == 0 {
+			it.seekFirstInSubtree()
+			return it.Valid()
+		}
+
+		// the node itself is smaller than str from here on,
+		// so only its children may be the next word
+		i, exists := frame.node.childIndex(endStr[0])
+		if exists {
+			it.push(frame.node.children[i], i)
+			continue
+		}
+
+		if i < len(frame.node.children) {
+			it.push(frame.node.children[i], i)
+			it.seekFirstInSubtree()
+		} else {
+			it.advancePastSubtree()
+		}
+		return it.Valid()
+	}
+}
+
+func (it *Iterator[V]) Next() bool {
+	if !it.Valid() {
+		return false
+	}
+
+	node := it.top().node
+	if len(node.children) > 0 {
+		it.push(node.children[0], 0)
+		it.seekFirstInSubtree()
+	} else {
+		it.advancePastSubtree()
+	}
+	return it.Valid()
+}
+
+func (it *Iterator[V]) Prev() bool {
+	if !it.Valid() {
+		return false
+	}
+
+	it.retreatBeforeSubtree()
+	return it.Valid()
+}
+
+func (it *Iterator[V]) reset() bool {
+	it.stack = it.stack[:0]
+	it.key = it.key[:0]
+	if it.root == nil {
+		return false
+	}
+
+	it.push(it.root, 0)
+	return true
+}
+
+func (it *Iterator[V]) top() *iteratorFrame[V] {
+	return &it.stack[len(it.stack)-1]
+}
+
+func (it *Iterator[V]) push(node *radixNode[V], index int) {
+	it.stack = append(it.stack, iteratorFrame[V]{
+		node:      node,
+		index:     index,
+		lenBefore: len(it.key),
+	})
+	it.key = append(it.key, node.part...)
+}
+
+func (it *Iterator[V]) pop() iteratorFrame[V] {
+	frame := *it.top()
+	it.stack = it.stack[:len(it.stack)-1]
+	it.key = it.key[:frame.lenBefore]
+	return frame
+}
+
+// seekFirstInSubtree moves from the current node to the first word in its
+// subtree, which is the node itself when it is final
+func (it *Iterator[V]) seekFirstInSubtree() {
+	for !it.top().node.final {
+		node := it.top().node
+		if len(node.children) == 0 {
+			it.advancePastSubtree()
+			return
+		}
+		it.push(node.children[0], 0)
+	}
+}
+
+// seekLastInSubtree moves from the current node to the last word in its
+// subtree, which is always the deepest node in its last child
+func (it *Iterator[V]) seekLastInSubtree() {
+	for len(it.top().node.children) > 0 {
+		node := it.top().node
+		it.push(node.children[len(node.children)-1], len(node.children)-1)
+	}
+
+	if !it.top().node.final {
+		it.retreatBeforeSubtree()
+	}
+}
+
+// advancePastSubtree moves to the first word after every word in the
+// subtree of the current node, invalidating the iterator if there is none
+func (it *Iterator[V]) advancePastSubtree() {
+	for {
+		frame := it.pop()
+		if !it.Valid() {
+			return
+		}
+
+		parent := it.top().node
+		if frame.index+1 < len(parent.children) {
+			it.push(parent.children[frame.index+1], frame.index+1)
+			it.seekFirstInSubtree()
+			return
+		}
+	}
+}
+
+// retreatBeforeSubtree moves to the last word before every word in the
+// subtree of the current node, invalidating the iterator if there is none
+func (it *Iterator[V]) retreatBeforeSubtree() {
+	for {
+		frame := it.pop()
+		if !it.Valid() {
+			return
+		}
+
+		parent := it.top().node
+		if frame.index > 0 {
+			it.push(parent.children[frame.index-1], frame.index-1)
+			it.seekLastInSubtree()
+			return
+		}
+
+		// a word comes right before the words it is a prefix of
+		if parent.final {
+			return
+		}
+	}
+}
+
+// SetIterator walks over the words of a Set in lexicographical order, in
+// either direction. It is positioned with First, Last or Seek, and it
+// must not be used anymore once the Set is modified.
+type SetIterator struct {
+	it *Iterator[struct{}]
+}
+
+func (it *SetIterator) Valid() bool {
+	return it.it.Valid()
+}
+
+func (it *SetIterator) Key() string {
+	return it.it.Key()
+}
+
+func (it *SetIterator) First() bool {
+	return it.it.First()
+}
+
+func (it *SetIterator) Last() bool {
+	return it.it.Last()
+}
+
+// Seek moves the iterator to the smallest word greater than or equal to str.
+func (it *SetIterator) Seek(str string) bool {
+	return it.it.Seek(str)
+}
+
+func (it *SetIterator) Next() bool {
+	return it.it.Next()
+}
+
+func (it *SetIterator) Prev() bool {
+	return it.it.Prev()
+}
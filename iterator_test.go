@@ -0,0 +1,167 @@
+package radixtree_test
+
+import (
+	"testing"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterator(t *testing.T) {
+	t.Parallel()
+
+	words := []string{"arm", "armor", "armored", "armory", "art", "b", "bee", "beetle"}
+	probes := []string{"", "a", "arm", "armo", "armor", "armored", "armorz", "art", "b", "bee", "beetles", "c"}
+
+	newMap := func() *radixtree.Map[int] {
+		rmap := &radixtree.Map[int]{}
+		for i, word := range words {
+			rmap.Add(word, i)
+		}
+		return rmap
+	}
+
+	t.Run("iterator on empty map is never valid", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		it := rmap.Iterator()
+
+		assert.False(t, it.Valid())
+		assert.False(t, it.First())
+		assert.False(t, it.Last())
+		assert.False(t, it.Seek("a"))
+		assert.False(t, it.Next())
+		assert.False(t, it.Prev())
+	})
+
+	t.Run("iterator is not valid before being positioned", func(t *testing.T) {
+		it := newMap().Iterator()
+
+		assert.False(t, it.Valid())
+		assert.False(t, it.Next())
+	})
+
+	t.Run("next visits words in ascending order", func(t *testing.T) {
+		it := newMap().Iterator()
+
+		keys := []string{}
+		values := []int{}
+		for ok := it.First(); ok; ok = it.Next() {
+			keys = append(keys, it.Key())
+			values = append(values, it.Value())
+		}
+
+		assert.Equal(t, words, keys)
+		assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, values)
+		assert.False(t, it.Valid())
+	})
+
+	t.Run("prev visits words in descending order", func(t *testing.T) {
+		it := newMap().Iterator()
+
+		keys := []string{}
+		for ok := it.Last(); ok; ok = it.Prev() {
+			keys = append(keys, it.Key())
+		}
+
+		assert.Equal(t, []string{"beetle", "bee", "b", "art", "armory", "armored", "armor", "arm"}, keys)
+		assert.False(t, it.Valid())
+	})
+
+	t.Run("seek positions at the smallest word not less than the key", func(t *testing.T) {
+		it := newMap().Iterator()
+
+		for _, probe := range probes {
+			expected, expectedFound := "", false
+			for i := len(words) - 1; i >= 0; i-- {
+				if words[i] >= probe {
+					expected, expectedFound = words[i], true
+				}
+			}
+
+			assert.Equal(t, expectedFound, it.Seek(probe), probe)
+			if expectedFound {
+				assert.Equal(t, expected, it.Key(), probe)
+			}
+		}
+	})
+
+	t.Run("next and prev can be interleaved", func(t *testing.T) {
+		it := newMap().Iterator()
+
+		assert.True(t, it.Seek("armo"))
+		assert.Equal(t, "armor", it.Key())
+
+		assert.True(t, it.Next())
+		assert.Equal(t, "armored", it.Key())
+
+		assert.True(t, it.Prev())
+		assert.Equal(t, "armor", it.Key())
+
+		assert.True(t, it.Prev())
+		assert.Equal(t, "arm", it.Key())
+
+		assert.False(t, it.Prev())
+		assert.False(t, it.Valid())
+
+		assert.True(t, it.Seek("art"))
+		assert.True(t, it.Prev())
+		assert.Equal(t, "armory", it.Key())
+		assert.EqualValues(t, 3, it.Value())
+	})
+
+	t.Run("iterators of two sets can be merged step by step", func(t *testing.T) {
+		left := radixtree.Set{}
+		left.Add("apple")
+		left.Add("banana")
+		left.Add("cherry")
+		left.Add("date")
+
+		right := radixtree.Set{}
+		right.Add("banana")
+		right.Add("blueberry")
+		right.Add("date")
+		right.Add("elderberry")
+
+		common := []string{}
+		lit, rit := left.Iterator(), right.Iterator()
+		lit.First()
+		rit.First()
+		for lit.Valid() && rit.Valid() {
+			switch {
+			case lit.Key() < rit.Key():
+				lit.Next()
+			case lit.Key() > rit.Key():
+				rit.Next()
+			default:
+				common = append(common, lit.Key())
+				lit.Next()
+				rit.Next()
+			}
+		}
+
+		assert.Equal(t, []string{"banana", "date"}, common)
+	})
+
+	t.Run("set iterator", func(t *testing.T) {
+		set := radixtree.Set{}
+		for _, word := range words {
+			set.Add(word)
+		}
+		it := set.Iterator()
+
+		assert.True(t, it.Seek("bed"))
+		assert.Equal(t, "bee", it.Key())
+
+		assert.True(t, it.Next())
+		assert.Equal(t, "beetle", it.Key())
+
+		assert.False(t, it.Next())
+
+		assert.True(t, it.Last())
+		assert.True(t, it.Prev())
+		assert.Equal(t, "bee", it.Key())
+
+		assert.True(t, it.First())
+		assert.Equal(t, "arm", it.Key())
+	})
+}
@@ -0,0 +1,142 @@
+package radixtree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// JSONValueDecoder decodes the JSON value of a key of a Map.
+type JSONValueDecoder[V any] func(key string, raw json.RawMessage) (V, error)
+
+func isJSONNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+// marshalJSONObject encodes the words of the tree as the keys of an object,
+// in lexicographical order
+func marshalJSONObject[V any](root *radixNode[V]) ([]byte, error) {
+	var buffer bytes.Buffer
+	var err error
+
+	buffer.WriteByte('{')
+	first := true
+	traverse(root, func(key string, data V) bool {
+		var encodedKey, encodedData []byte
+		if encodedKey, err = json.Marshal(key); err != nil {
+			return false
+		}
+		if encodedData, err = json.Marshal(data); err != nil {
+			return false
+		}
+		if !first {
+			buffer.WriteByte(',')
+		}
+		first = false
+		buffer.Write(encodedKey)
+		buffer.WriteByte(':')
+		buffer.Write(encodedData)
+		return true
+	})
+	buffer.WriteByte('}')
+
+	return buffer.Bytes(), err
+}
+
+// unmarshalJSONObject builds a tree owned by the generation gen from the
+// entries of an object, where the last one wins among repeated keys
+func unmarshalJSONObject[V any](data []byte, gen uint64, decodeValue JSONValueDecoder[V]) (*radixNode[V], error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != json.Delim('{') {
+		return nil, fmt.Errorf("radixtree: expected a JSON object, found %v", token)
+	}
+
+	var root *radixNode[V]
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := token.(string)
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+
+		var value V
+		if decodeValue != nil {
+			value, err = decodeValue(key, raw)
+		} else {
+			err = json.Unmarshal(raw, &value)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		root, _, _ = add(root, key, gen, value)
+	}
+
+	// the closing brace
+	_, err = decoder.Token()
+	return root, err
+}
+
+func marshalJSONArray(root *radixNode[struct{}]) ([]byte, error) {
+	words := []string{}
+	traverse(root, func(word string, _ struct{}) bool {
+		words = append(words, word)
+		return true
+	})
+	return json.Marshal(words)
+}
+
+func unmarshalJSONArray(data []byte, gen uint64) (*radixNode[struct{}], error) {
+	var words []string
+	if err := json.Unmarshal(data, &words); err != nil {
+		return nil, err
+	}
+
+	var root *radixNode[struct{}]
+	for _, word := range words {
+		root, _, _ = add(root, word, gen, struct{}{})
+	}
+	return root, nil
+}
+
+type jsonNode struct {
+	Part     string     `json:"part"`
+	Final    bool       `json:"final,omitempty"`
+	Value    any        `json:"value,omitempty"`
+	Count    int64      `json:"count"`
+	Children []jsonNode `json:"children,omitempty"`
+}
+
+// marshalJSONTree mirrors the structure of the nodes, for debugging.
+// Values are left out when withValues is false.
+func marshalJSONTree[V any](root *radixNode[V], withValues bool) ([]byte, error) {
+	if root == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(newJSONNode(root, withValues))
+}
+
+func newJSONNode[V any](node *radixNode[V], withValues bool) jsonNode {
+	jnode := jsonNode{
+		Part:  node.part,
+		Final: node.final,
+		Count: node.count,
+	}
+	if node.final && withValues {
+		jnode.Value = node.data
+	}
+	for _, child := range node.children {
+		jnode.Children = append(jnode.Children, newJSONNode(child, withValues))
+	}
+	return jnode
+}
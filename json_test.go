@@ -0,0 +1,172 @@
+package radixtree_test
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("object with sorted keys", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		rmap.Add("romulus", 3)
+		rmap.Add("romane", 1)
+		rmap.Add("", 0)
+		rmap.Add("romanus", 2)
+
+		encoded, err := json.Marshal(&rmap)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"":0,"romane":1,"romanus":2,"romulus":3}`, string(encoded))
+
+		decoded := radixtree.Map[int]{}
+		assert.NoError(t, json.Unmarshal(encoded, &decoded))
+		assert.Equal(t, maps.Collect(rmap.All()), maps.Collect(decoded.All()))
+		assert.EqualValues(t, 4, decoded.Size())
+	})
+
+	t.Run("inside other values", func(t *testing.T) {
+		type config struct {
+			Routes radixtree.Map[string] `json:"routes"`
+		}
+
+		var c config
+		assert.NoError(t, json.Unmarshal([]byte(`{"routes": {"/api/": "backend", "/": "static"}}`), &c))
+
+		key, data, found := c.Routes.LongestPrefix("/api/users")
+		assert.True(t, found)
+		assert.Equal(t, "/api/", key)
+		assert.Equal(t, "backend", data)
+
+		encoded, err := json.Marshal(&c)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"routes":{"/":"static","/api/":"backend"}}`, string(encoded))
+	})
+
+	t.Run("by value", func(t *testing.T) {
+		type message struct {
+			Routes radixtree.Map[string] `json:"routes"`
+			Hosts  radixtree.Set         `json:"hosts"`
+		}
+
+		m := message{}
+		m.Routes.Add("/api/", "backend")
+		m.Hosts.Add("b.example")
+		m.Hosts.Add("a.example")
+
+		encoded, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"routes":{"/api/":"backend"},"hosts":["a.example","b.example"]}`, string(encoded))
+
+		encoded, err = json.Marshal(m.Routes)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"/api/":"backend"}`, string(encoded))
+	})
+
+	t.Run("typed value decoding hook", func(t *testing.T) {
+		rmap := radixtree.Map[any]{}
+		rmap.SetJSONValueDecoder(func(key string, raw json.RawMessage) (any, error) {
+			if key == "limit" {
+				var limit int
+				err := json.Unmarshal(raw, &limit)
+				return limit, err
+			}
+			var text string
+			err := json.Unmarshal(raw, &text)
+			return text, err
+		})
+
+		assert.NoError(t, json.Unmarshal([]byte(`{"limit": 10, "name": "bliss"}`), &rmap))
+
+		limit, _ := rmap.Get("limit")
+		assert.Equal(t, 10, limit)
+		name, _ := rmap.Get("name")
+		assert.Equal(t, "bliss", name)
+
+		assert.Error(t, json.Unmarshal([]byte(`{"limit": "ten"}`), &rmap))
+	})
+
+	t.Run("errors leave the map unchanged", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		rmap.Add("kept", 1)
+
+		assert.Error(t, json.Unmarshal([]byte(`["a", "b"]`), &rmap))
+		assert.Error(t, json.Unmarshal([]byte(`{"a": "b"}`), &rmap))
+		assert.NoError(t, json.Unmarshal([]byte(`null`), &rmap))
+
+		assert.Equal(t, map[string]int{"kept": 1}, maps.Collect(rmap.All()))
+	})
+
+	t.Run("tree form", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		rmap.Add("romane", 1)
+		rmap.Add("romanus", 2)
+		rmap.Add("romulus", 3)
+
+		encoded, err := rmap.MarshalJSONTree()
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"part": "rom", "count": 3, "children": [
+				{"part": "an", "count": 2, "children": [
+					{"part": "e", "final": true, "value": 1, "count": 1},
+					{"part": "us", "final": true, "value": 2, "count": 1}
+				]},
+				{"part": "ulus", "final": true, "value": 3, "count": 1}
+			]
+		}`, string(encoded))
+
+		empty := radixtree.Map[int]{}
+		encoded, err = empty.MarshalJSONTree()
+		assert.NoError(t, err)
+		assert.Equal(t, "null", string(encoded))
+	})
+}
+
+func TestSetJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sorted array", func(t *testing.T) {
+		set := radixtree.Set{}
+		for i := 20; i > 0; i-- {
+			set.Add(strconv.Itoa(i))
+		}
+
+		encoded, err := json.Marshal(&set)
+		assert.NoError(t, err)
+
+		var words []string
+		assert.NoError(t, json.Unmarshal(encoded, &words))
+		assert.True(t, slices.IsSorted(words))
+		assert.Len(t, words, 20)
+
+		decoded := radixtree.Set{}
+		assert.NoError(t, json.Unmarshal([]byte(`["b", "a", "b", "ab"]`), &decoded))
+		assert.Equal(t, []string{"a", "ab", "b"}, slices.Collect(decoded.All()))
+		assert.EqualValues(t, 3, decoded.Size())
+
+		empty := radixtree.Set{}
+		encoded, err = json.Marshal(&empty)
+		assert.NoError(t, err)
+		assert.Equal(t, "[]", string(encoded))
+	})
+
+	t.Run("tree form", func(t *testing.T) {
+		set := radixtree.Set{}
+		set.Add("bliss")
+		set.Add("blissful")
+
+		encoded, err := set.MarshalJSONTree()
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"part": "bliss", "final": true, "count": 2, "children": [
+				{"part": "ful", "final": true, "count": 1}
+			]
+		}`, string(encoded))
+	})
+}
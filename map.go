@@ -1,40 +1,382 @@
 package radixtree
 
-type Map struct {
-	root *radixNode
+import (
+	"bytes"
+	"io"
+	"iter"
+)
+
+type Map[V any] struct {
+	root *radixNode[V]
 	size int64
+	// generation of the nodes the map can change in place
+	gen uint64
+	// converts the values when serializing, defaulting to GobCodec
+	codec ValueCodec[V]
+	// decodes the JSON values, defaulting to json.Unmarshal
+	jsonDecoder JSONValueDecoder[V]
 }
 
-func (m *Map) Add(str string, data interface{}) {
-	m.root = add(m.root, str, data)
-	m.size++
+func (m *Map[V]) Add(str string, data V) {
+	m.Put(str, data)
 }
 
-func (m *Map) Remove(str string) {
-	var removed bool
-	m.root, removed = remove(m.root, str)
-	if removed {
-		m.size--
+func (m *Map[V]) Put(str string, data V) (V, bool) {
+	var old V
+	var added bool
+	m.root, old, added = add(m.root, str, m.gen, data)
+	if added {
+		m.size++
 	}
+	return old, !added
 }
 
-func (m *Map) Get(str string) (interface{}, bool) {
+func (m *Map[V]) Remove(str string) {
+	m.LoadAndDelete(str)
+}
+
+func (m *Map[V]) Get(str string) (V, bool) {
 	node := get(m.root, str)
 	if node == nil || !node.final {
-		return nil, false
+		var zero V
+		return zero, false
 	}
 	return node.data, true
 }
 
-func (m *Map) Size() int64 {
+func (m *Map[V]) Size() int64 {
 	return m.size
 }
 
-func (m *Map) ForEach(action func(string, interface{})) {
-	traverse(m.root, action)
+func (m *Map[V]) ForEach(action func(string, V)) {
+	traverse(m.root, continuing(action))
+}
+
+func (m *Map[V]) ForEachWithPrefix(prefix string, action func(string, V)) {
+	node, buffer := getWithPrefix(m.root, prefix)
+	traverseRecursive(node, buffer, continuing(action))
+}
+
+func (m *Map[V]) ForEachReverse(action func(string, V)) {
+	traverseReverse(m.root, continuing(action))
+}
+
+func (m *Map[V]) ForEachWithPrefixReverse(prefix string, action func(string, V)) {
+	node, buffer := getWithPrefix(m.root, prefix)
+	traverseReverseRecursive(node, buffer, continuing(action))
+}
+
+func (m *Map[V]) Min() (string, V, bool) {
+	buffer := &bytes.Buffer{}
+	return nodeEntry(minimum(m.root, buffer), buffer)
+}
+
+func (m *Map[V]) Max() (string, V, bool) {
+	buffer := &bytes.Buffer{}
+	return nodeEntry(maximum(m.root, buffer), buffer)
+}
+
+func (m *Map[V]) Floor(str string) (string, V, bool) {
+	buffer := &bytes.Buffer{}
+	return nodeEntry(floor(m.root, str, false, buffer), buffer)
+}
+
+func (m *Map[V]) Ceiling(str string) (string, V, bool) {
+	buffer := &bytes.Buffer{}
+	return nodeEntry(ceiling(m.root, str, false, buffer), buffer)
+}
+
+func (m *Map[V]) Predecessor(str string) (string, V, bool) {
+	buffer := &bytes.Buffer{}
+	return nodeEntry(floor(m.root, str, true, buffer), buffer)
+}
+
+func (m *Map[V]) Successor(str string) (string, V, bool) {
+	buffer := &bytes.Buffer{}
+	return nodeEntry(ceiling(m.root, str, true, buffer), buffer)
+}
+
+func (m *Map[V]) ForEachInRange(from, to string, action func(string, V), opts ...RangeOption) {
+	traverseRange(m.root, newRangeBounds(from, to, opts), continuing(action))
+}
+
+func (m *Map[V]) CountInRange(from, to string, opts ...RangeOption) int64 {
+	return countInRange(m.root, newRangeBounds(from, to, opts))
 }
 
-func (m *Map) ForEachWithPrefix(prefix string, action func(string, interface{})) {
+func (m *Map[V]) LongestPrefix(str string) (string, V, bool) {
+	node, length := longestPrefix(m.root, str, 0)
+	if node == nil {
+		var zero V
+		return "", zero, false
+	}
+	return str[:length], node.data, true
+}
+
+func (m *Map[V]) ForEachPrefixOf(str string, action func(string, V)) {
+	traversePrefixesOf(m.root, str, action)
+}
+
+// Snapshot returns a frozen view of the map in constant time. The nodes become
+// shared with it, so the map copies the ones it changes from then on.
+func (m *Map[V]) Snapshot() ImmutableMap[V] {
+	snapshot := ImmutableMap[V]{m: *m}
+	m.gen = newGeneration()
+	return snapshot
+}
+
+func (m *Map[V]) Iterator() *Iterator[V] {
+	return newIterator(m.root)
+}
+
+func (m *Map[V]) All() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		traverse(m.root, yield)
+	}
+}
+
+func (m *Map[V]) Keys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		traverse(m.root, func(s string, _ V) bool {
+			return yield(s)
+		})
+	}
+}
+
+func (m *Map[V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		traverse(m.root, func(_ string, data V) bool {
+			return yield(data)
+		})
+	}
+}
+
+func (m *Map[V]) WithPrefix(prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		node, buffer := getWithPrefix(m.root, prefix)
+		traverseRecursive(node, buffer, yield)
+	}
+}
+
+func (m *Map[V]) Range(from, to string, opts ...RangeOption) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		traverseRange(m.root, newRangeBounds(from, to, opts), yield)
+	}
+}
+
+func (m *Map[V]) Backward() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		traverseReverse(m.root, yield)
+	}
+}
+
+func (m *Map[V]) Walk(action func(string, V) WalkControl) {
+	walk(m.root, action)
+}
+
+func (m *Map[V]) WalkWithPrefix(prefix string, action func(string, V) WalkControl) {
 	node, buffer := getWithPrefix(m.root, prefix)
-	traverseRecursive(node, buffer, action)
+	walkRecursive(node, buffer, action)
+}
+
+func (m *Map[V]) RemovePrefix(prefix string) int64 {
+	var removed int64
+	m.root, removed = removePrefix(m.root, prefix, m.gen)
+	m.size -= removed
+	return removed
+}
+
+func (m *Map[V]) CountWithPrefix(prefix string) int64 {
+	return countWithPrefix(m.root, prefix)
+}
+
+func (m *Map[V]) Rank(str string) int64 {
+	return rank(m.root, str)
+}
+
+func (m *Map[V]) Select(index int64) (string, V, bool) {
+	buffer := &bytes.Buffer{}
+	return nodeEntry(selectAt(m.root, index, buffer), buffer)
+}
+
+func (m *Map[V]) modify(str string, decide func(old V, exists bool) (V, modification)) {
+	var delta int64
+	m.root, delta = modify(m.root, str, m.gen, decide)
+	m.size += delta
+}
+
+// GetOrAdd returns the value associated with str if there is one,
+// otherwise it associates data with str and returns it.
+// The returned bool is true if the value was already there.
+func (m *Map[V]) GetOrAdd(str string, data V) (V, bool) {
+	actual, loaded := data, false
+	m.modify(str, func(old V, exists bool) (V, modification) {
+		if exists {
+			actual, loaded = old, true
+			return old, keepWord
+		}
+		return data, setWord
+	})
+	return actual, loaded
+}
+
+// Update associates str with the value returned by update, which receives
+// the current one, or removes str if update does not want to keep it.
+// It returns the new value and whether str is in the map afterwards.
+func (m *Map[V]) Update(str string, update func(old V, exists bool) (V, bool)) (V, bool) {
+	var data V
+	var keep bool
+	m.modify(str, func(old V, exists bool) (V, modification) {
+		data, keep = update(old, exists)
+		if !keep {
+			var zero V
+			data = zero
+			return old, deleteWord
+		}
+		return data, setWord
+	})
+	return data, keep
+}
+
+// Upsert associates str with the value returned by upsert,
+// which receives the current one, and returns the new value.
+func (m *Map[V]) Upsert(str string, upsert func(old V, exists bool) V) V {
+	var data V
+	m.modify(str, func(old V, exists bool) (V, modification) {
+		data = upsert(old, exists)
+		return data, setWord
+	})
+	return data
+}
+
+// CompareAndSwap associates str with data if it is currently associated
+// with old, and reports whether it did. Like in sync.Map, the values are
+// compared with ==, so old must be of a comparable type.
+func (m *Map[V]) CompareAndSwap(str string, old, data V) bool {
+	swapped := false
+	m.modify(str, func(current V, exists bool) (V, modification) {
+		if !exists || any(current) != any(old) {
+			return current, keepWord
+		}
+		swapped = true
+		return data, setWord
+	})
+	return swapped
+}
+
+// Swap associates str with data, returning the previous value, if any.
+func (m *Map[V]) Swap(str string, data V) (V, bool) {
+	return m.Put(str, data)
+}
+
+// LoadAndDelete removes str, returning its value, if any.
+func (m *Map[V]) LoadAndDelete(str string) (V, bool) {
+	var old V
+	var removed bool
+	m.root, old, removed = remove(m.root, str, m.gen)
+	if removed {
+		m.size--
+	}
+	return old, removed
+}
+
+func (m *Map[V]) SetValueCodec(codec ValueCodec[V]) {
+	m.codec = codec
+}
+
+func (m *Map[V]) valueCodec() ValueCodec[V] {
+	if m.codec == nil {
+		return GobCodec[V]{}
+	}
+	return m.codec
+}
+
+func (m *Map[V]) WriteTo(w io.Writer) (int64, error) {
+	return writeTree(w, m.root, m.valueCodec().EncodeValue)
+}
+
+// ReadFrom replaces the contents with the ones read from r, leaving them
+// unchanged if the encoding is invalid. It reads nothing past the end of
+// the tree, so several trees can be read from one stream.
+func (m *Map[V]) ReadFrom(r io.Reader) (int64, error) {
+	root, n, err := readTree(r, m.gen, m.valueCodec().DecodeValue)
+	if err == nil {
+		m.replaceRoot(root)
+	}
+	return n, err
+}
+
+func (m *Map[V]) replaceRoot(root *radixNode[V]) {
+	m.root = root
+	m.size = 0
+	if root != nil {
+		m.size = root.count
+	}
+}
+
+func (m *Map[V]) MarshalBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	_, err := m.WriteTo(&buffer)
+	return buffer.Bytes(), err
+}
+
+func (m *Map[V]) UnmarshalBinary(data []byte) error {
+	root, err := unmarshalTree(data, m.gen, m.valueCodec().DecodeValue)
+	if err != nil {
+		return err
+	}
+	m.replaceRoot(root)
+	return nil
+}
+
+// MarshalJSON encodes the map as an object, with the keys in
+// lexicographical order.
+func (m Map[V]) MarshalJSON() ([]byte, error) {
+	return marshalJSONObject(m.root)
+}
+
+func (m *Map[V]) SetJSONValueDecoder(decoder JSONValueDecoder[V]) {
+	m.jsonDecoder = decoder
+}
+
+// UnmarshalJSON replaces the contents with the entries of an object,
+// leaving them unchanged if it fails.
+func (m *Map[V]) UnmarshalJSON(data []byte) error {
+	if isJSONNull(data) {
+		return nil
+	}
+	root, err := unmarshalJSONObject(data, m.gen, m.jsonDecoder)
+	if err != nil {
+		return err
+	}
+	m.replaceRoot(root)
+	return nil
+}
+
+// MarshalJSONTree encodes the nodes of the tree as nested objects,
+// which is useful for debugging.
+func (m Map[V]) MarshalJSONTree() ([]byte, error) {
+	return marshalJSONTree(m.root, true)
+}
+
+// Save writes the map in the file format described in file.go.
+func (m *Map[V]) Save(w io.Writer) error {
+	return writeFile(w, fileKindMap, m.root, m.valueCodec().EncodeValue)
+}
+
+// Load replaces the contents with the ones of a file written by Save,
+// leaving them unchanged if the file is invalid.
+func (m *Map[V]) Load(r io.Reader) error {
+	root, err := readFile(r, fileKindMap, m.gen, m.valueCodec().DecodeValue)
+	if err != nil {
+		return err
+	}
+	m.replaceRoot(root)
+	return nil
+}
+
+// WriteImage writes the map in the flat layout described in image.go,
+// which OpenMappedMap searches without loading it.
+func (m *Map[V]) WriteImage(w io.Writer) error {
+	return writeImage(w, fileKindMap, m.root, m.valueCodec().EncodeValue)
 }
@@ -1,6 +1,9 @@
 package radixtree_test
 
 import (
+	"maps"
+	"slices"
+	"strconv"
 	"testing"
 
 	"github.com/jpholanda/radixtree"
@@ -9,21 +12,21 @@ import (
 
 type pair struct {
 	key  string
-	data interface{}
+	data int
 }
 
 func TestMapBasic(t *testing.T) {
 	t.Parallel()
 
 	t.Run("empty contains nothing", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		_, exists := rmap.Get("aaa")
 		assert.False(t, exists)
 	})
 
 	t.Run("contain after add", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("bbb", 222)
 
@@ -34,7 +37,7 @@ func TestMapBasic(t *testing.T) {
 	})
 
 	t.Run("not contain after add and remove", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("ccc", 333)
 		rmap.Remove("ccc")
@@ -45,7 +48,7 @@ func TestMapBasic(t *testing.T) {
 	})
 
 	t.Run("size after add", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("ddd", 444)
 
@@ -53,7 +56,7 @@ func TestMapBasic(t *testing.T) {
 	})
 
 	t.Run("size after remove", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Remove("eee")
 
@@ -61,7 +64,7 @@ func TestMapBasic(t *testing.T) {
 	})
 
 	t.Run("size after add then remove", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("fff", 666)
 		rmap.Remove("fff")
@@ -69,23 +72,61 @@ func TestMapBasic(t *testing.T) {
 		assert.EqualValues(t, 0, rmap.Size())
 	})
 
+	t.Run("size after adding the same key twice", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("ddd", 444)
+		rmap.Add("ddd", 555)
+
+		assert.EqualValues(t, 1, rmap.Size())
+
+		data, exists := rmap.Get("ddd")
+		assert.True(t, exists)
+		assert.EqualValues(t, 555, data)
+	})
+
+	t.Run("put reports new key", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		old, replaced := rmap.Put("lll", 121212)
+
+		assert.False(t, replaced)
+		assert.EqualValues(t, 0, old)
+		assert.EqualValues(t, 1, rmap.Size())
+	})
+
+	t.Run("put reports replaced value", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Put("mmm", 131313)
+		old, replaced := rmap.Put("mmm", 141414)
+
+		assert.True(t, replaced)
+		assert.EqualValues(t, 131313, old)
+		assert.EqualValues(t, 1, rmap.Size())
+
+		data, exists := rmap.Get("mmm")
+		assert.True(t, exists)
+		assert.EqualValues(t, 141414, data)
+	})
+
 	t.Run("for each when empty", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
-		rmap.ForEach(func(_ string, _ interface{}) {
+		rmap.ForEach(func(_ string, _ int) {
 			panic("should not be executed")
 		})
 	})
 
 	t.Run("for each when not empty", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("ggg", 777)
 		rmap.Add("hhh", 888)
 		rmap.Add("iii", 999)
 
 		count := map[pair]int{}
-		rmap.ForEach(func(s string, data interface{}) {
+		rmap.ForEach(func(s string, data int) {
 			count[pair{key: s, data: data}]++
 		})
 
@@ -96,24 +137,24 @@ func TestMapBasic(t *testing.T) {
 	})
 
 	t.Run("for each with prefix not found", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("jjj", 101010)
 
-		rmap.ForEachWithPrefix("k", func(_ string, _ interface{}) {
+		rmap.ForEachWithPrefix("k", func(_ string, _ int) {
 			panic("executing foreach with prefix not found")
 		})
 	})
 
 	t.Run("for each with prefix found", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("kk0", 10100)
 		rmap.Add("kk1", 10101)
 		rmap.Add("kk2", 10102)
 
 		count := map[pair]int{}
-		rmap.ForEachWithPrefix("k", func(s string, data interface{}) {
+		rmap.ForEachWithPrefix("k", func(s string, data int) {
 			count[pair{key: s, data: data}]++
 		})
 
@@ -128,7 +169,7 @@ func TestMapThorough(t *testing.T) {
 	t.Parallel()
 
 	t.Run("does not contain prefix if not added", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("catastrophic", 0)
 
@@ -143,7 +184,7 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("contains prefix if added", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("catastrophic", 0)
 		rmap.Add("cat", 1)
@@ -160,7 +201,7 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("does not contain common prefix if not added", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("butterfly", 22)
 		rmap.Add("butterscotch", 33)
@@ -183,7 +224,7 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("contains common prefix if added", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("butterfly", 22)
 		rmap.Add("butterscotch", 33)
@@ -205,7 +246,7 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("contains word and prefix if added after prefix", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("trust", 5)
 		rmap.Add("trustworthy", 6)
@@ -221,8 +262,28 @@ func TestMapThorough(t *testing.T) {
 		assert.EqualValues(t, 6, data)
 	})
 
+	t.Run("put on existing prefix and common prefix", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Put("butterfly", 22)
+		rmap.Put("butterscotch", 33)
+
+		_, replaced := rmap.Put("butter", 44)
+		assert.False(t, replaced)
+
+		old, replaced := rmap.Put("butter", 55)
+		assert.True(t, replaced)
+		assert.EqualValues(t, 44, old)
+
+		old, replaced = rmap.Put("butterfly", 66)
+		assert.True(t, replaced)
+		assert.EqualValues(t, 22, old)
+
+		assert.EqualValues(t, 3, rmap.Size())
+	})
+
 	t.Run("removing prefix does not remove word", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("bittersweet", 100)
 		rmap.Remove("bitter")
@@ -238,7 +299,7 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("removing word does not remove prefix", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("bitter", 100)
 		rmap.Remove("bittersweet")
@@ -254,7 +315,7 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("remove common prefix does not remove words", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("hearing", 50)
 		rmap.Add("heartless", 55)
@@ -276,7 +337,7 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("remove existing common prefix", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("hearing", 123)
 		rmap.Add("hear", 456)
@@ -299,7 +360,7 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("add word with two existing prefixes", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("bliss", 1001)
 		rmap.Add("blissful", 2002)
@@ -321,7 +382,7 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("remove middle prefix of existing word", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("bliss", 81)
 		rmap.Add("blissful", 90)
@@ -344,7 +405,7 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("remove existing word with existing prefix", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("work", 35)
 		rmap.Add("worker", 57)
@@ -362,7 +423,7 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("remove existing word with existing common prefix", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("worker", 57)
 		rmap.Add("workaholic", 79)
@@ -383,22 +444,22 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("for each with prefix when empty", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
-		rmap.ForEachWithPrefix("abcdef", func(_ string, _ interface{}) {
+		rmap.ForEachWithPrefix("abcdef", func(_ string, _ int) {
 			panic("executing foreach with prefix on empty rmap")
 		})
 	})
 
 	t.Run("for each with existing middle prefix", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("arm", 21)
 		rmap.Add("armor", 23)
 		rmap.Add("armored", 25)
 
 		count := map[pair]int{}
-		rmap.ForEachWithPrefix("armor", func(s string, data interface{}) {
+		rmap.ForEachWithPrefix("armor", func(s string, data int) {
 			count[pair{key: s, data: data}]++
 		})
 
@@ -408,13 +469,1056 @@ func TestMapThorough(t *testing.T) {
 	})
 
 	t.Run("for each with not existing prefix but existing prefix of prefix", func(t *testing.T) {
-		rmap := radixtree.Map{}
+		rmap := radixtree.Map[int]{}
 
 		rmap.Add("arm", 21)
 		rmap.Add("armored", 25)
 
-		rmap.ForEachWithPrefix("armenia", func(_ string, _ interface{}) {
+		rmap.ForEachWithPrefix("armenia", func(_ string, _ int) {
 			panic("executing foreach with prefix not found")
 		})
 	})
 }
+
+func TestMapOrder(t *testing.T) {
+	t.Parallel()
+
+	t.Run("for each visits keys in ascending order", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("romulus", 1)
+		rmap.Add("rubicon", 2)
+		rmap.Add("romane", 3)
+		rmap.Add("rom", 4)
+		rmap.Add("ruber", 5)
+		rmap.Add("a", 6)
+
+		keys := []string{}
+		values := []int{}
+		rmap.ForEach(func(s string, data int) {
+			keys = append(keys, s)
+			values = append(values, data)
+		})
+
+		assert.Equal(t, []string{"a", "rom", "romane", "romulus", "ruber", "rubicon"}, keys)
+		assert.Equal(t, []int{6, 4, 3, 1, 5, 2}, values)
+	})
+
+	t.Run("for each reverse visits keys in descending order", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("romulus", 1)
+		rmap.Add("rubicon", 2)
+		rmap.Add("romane", 3)
+		rmap.Add("rom", 4)
+		rmap.Add("ruber", 5)
+		rmap.Add("a", 6)
+
+		keys := []string{}
+		values := []int{}
+		rmap.ForEachReverse(func(s string, data int) {
+			keys = append(keys, s)
+			values = append(values, data)
+		})
+
+		assert.Equal(t, []string{"rubicon", "ruber", "romulus", "romane", "rom", "a"}, keys)
+		assert.Equal(t, []int{2, 5, 1, 3, 4, 6}, values)
+	})
+
+	t.Run("for each with prefix visits keys in ascending order", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("kk2", 10102)
+		rmap.Add("kk0", 10100)
+		rmap.Add("kk", 10000)
+		rmap.Add("kk1", 10101)
+		rmap.Add("l", 20000)
+
+		keys := []string{}
+		rmap.ForEachWithPrefix("kk", func(s string, _ int) {
+			keys = append(keys, s)
+		})
+
+		assert.Equal(t, []string{"kk", "kk0", "kk1", "kk2"}, keys)
+	})
+
+	t.Run("for each with prefix reverse visits keys in descending order", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("kk2", 10102)
+		rmap.Add("kk0", 10100)
+		rmap.Add("kk", 10000)
+		rmap.Add("kk1", 10101)
+		rmap.Add("l", 20000)
+
+		keys := []string{}
+		rmap.ForEachWithPrefixReverse("kk", func(s string, _ int) {
+			keys = append(keys, s)
+		})
+
+		assert.Equal(t, []string{"kk2", "kk1", "kk0", "kk"}, keys)
+	})
+
+	t.Run("order is kept after removals", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("hearing", 1)
+		rmap.Add("hear", 2)
+		rmap.Add("heartless", 3)
+		rmap.Add("heap", 4)
+		rmap.Add("he", 5)
+
+		rmap.Remove("hear")
+		rmap.Remove("he")
+
+		keys := []string{}
+		rmap.ForEach(func(s string, _ int) {
+			keys = append(keys, s)
+		})
+
+		assert.Equal(t, []string{"heap", "hearing", "heartless"}, keys)
+	})
+}
+
+func TestMapNavigation(t *testing.T) {
+	t.Parallel()
+
+	newMap := func() *radixtree.Map[int] {
+		rmap := &radixtree.Map[int]{}
+		rmap.Add("bliss", 1)
+		rmap.Add("blissful", 2)
+		rmap.Add("blissfulness", 3)
+		rmap.Add("blister", 4)
+		rmap.Add("cat", 5)
+		return rmap
+	}
+
+	t.Run("min and max when empty", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		_, _, found := rmap.Min()
+		assert.False(t, found)
+
+		_, _, found = rmap.Max()
+		assert.False(t, found)
+	})
+
+	t.Run("min and max", func(t *testing.T) {
+		rmap := newMap()
+
+		key, data, found := rmap.Min()
+		assert.True(t, found)
+		assert.Equal(t, "bliss", key)
+		assert.EqualValues(t, 1, data)
+
+		key, data, found = rmap.Max()
+		assert.True(t, found)
+		assert.Equal(t, "cat", key)
+		assert.EqualValues(t, 5, data)
+	})
+
+	t.Run("floor and ceiling of existing key", func(t *testing.T) {
+		rmap := newMap()
+
+		key, data, found := rmap.Floor("blissful")
+		assert.True(t, found)
+		assert.Equal(t, "blissful", key)
+		assert.EqualValues(t, 2, data)
+
+		key, data, found = rmap.Ceiling("blissful")
+		assert.True(t, found)
+		assert.Equal(t, "blissful", key)
+		assert.EqualValues(t, 2, data)
+	})
+
+	t.Run("floor and ceiling of key between prefixes", func(t *testing.T) {
+		rmap := newMap()
+
+		key, data, found := rmap.Floor("blissfu")
+		assert.True(t, found)
+		assert.Equal(t, "bliss", key)
+		assert.EqualValues(t, 1, data)
+
+		key, data, found = rmap.Ceiling("blissfu")
+		assert.True(t, found)
+		assert.Equal(t, "blissful", key)
+		assert.EqualValues(t, 2, data)
+	})
+
+	t.Run("floor and ceiling of key diverging from the tree", func(t *testing.T) {
+		rmap := newMap()
+
+		key, _, found := rmap.Floor("blissg")
+		assert.True(t, found)
+		assert.Equal(t, "blissfulness", key)
+
+		key, _, found = rmap.Ceiling("blissg")
+		assert.True(t, found)
+		assert.Equal(t, "blister", key)
+	})
+
+	t.Run("floor and ceiling out of bounds", func(t *testing.T) {
+		rmap := newMap()
+
+		_, _, found := rmap.Floor("a")
+		assert.False(t, found)
+
+		_, _, found = rmap.Ceiling("dog")
+		assert.False(t, found)
+	})
+
+	t.Run("predecessor and successor of existing key", func(t *testing.T) {
+		rmap := newMap()
+
+		key, data, found := rmap.Predecessor("blissful")
+		assert.True(t, found)
+		assert.Equal(t, "bliss", key)
+		assert.EqualValues(t, 1, data)
+
+		key, data, found = rmap.Successor("blissful")
+		assert.True(t, found)
+		assert.Equal(t, "blissfulness", key)
+		assert.EqualValues(t, 3, data)
+	})
+
+	t.Run("predecessor of min and successor of max", func(t *testing.T) {
+		rmap := newMap()
+
+		_, _, found := rmap.Predecessor("bliss")
+		assert.False(t, found)
+
+		_, _, found = rmap.Successor("cat")
+		assert.False(t, found)
+	})
+
+	t.Run("successor of last key in subtree", func(t *testing.T) {
+		rmap := newMap()
+
+		key, _, found := rmap.Successor("blister")
+		assert.True(t, found)
+		assert.Equal(t, "cat", key)
+
+		key, _, found = rmap.Predecessor("cat")
+		assert.True(t, found)
+		assert.Equal(t, "blister", key)
+	})
+}
+
+func TestMapRange(t *testing.T) {
+	t.Parallel()
+
+	newMap := func() *radixtree.Map[int] {
+		rmap := &radixtree.Map[int]{}
+		rmap.Add("order/2023-12-31", 1)
+		rmap.Add("order/2024-01", 2)
+		rmap.Add("order/2024-01-15", 3)
+		rmap.Add("order/2024-01-31", 4)
+		rmap.Add("order/2024-02", 5)
+		rmap.Add("order/2024-02-01", 6)
+		rmap.Add("user/1", 7)
+		return rmap
+	}
+
+	t.Run("for each in range when empty", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.ForEachInRange("a", "z", func(_ string, _ int) {
+			panic("executing foreach in range on empty map")
+		})
+	})
+
+	t.Run("for each in range includes from and excludes to by default", func(t *testing.T) {
+		rmap := newMap()
+
+		keys := []string{}
+		values := []int{}
+		rmap.ForEachInRange("order/2024-01", "order/2024-02", func(s string, data int) {
+			keys = append(keys, s)
+			values = append(values, data)
+		})
+
+		assert.Equal(t, []string{"order/2024-01", "order/2024-01-15", "order/2024-01-31"}, keys)
+		assert.Equal(t, []int{2, 3, 4}, values)
+	})
+
+	t.Run("for each in range with exclusive from and inclusive to", func(t *testing.T) {
+		rmap := newMap()
+
+		keys := []string{}
+		rmap.ForEachInRange("order/2024-01", "order/2024-02", func(s string, _ int) {
+			keys = append(keys, s)
+		}, radixtree.ExclusiveFrom(), radixtree.InclusiveTo())
+
+		assert.Equal(t, []string{"order/2024-01-15", "order/2024-01-31", "order/2024-02"}, keys)
+	})
+
+	t.Run("for each in range with bounds not in the map", func(t *testing.T) {
+		rmap := newMap()
+
+		keys := []string{}
+		rmap.ForEachInRange("order/2024-01-2", "order/2024-02-0", func(s string, _ int) {
+			keys = append(keys, s)
+		})
+
+		assert.Equal(t, []string{"order/2024-01-31", "order/2024-02"}, keys)
+	})
+
+	t.Run("for each in empty range", func(t *testing.T) {
+		rmap := newMap()
+
+		rmap.ForEachInRange("order/2024-02", "order/2024-01", func(_ string, _ int) {
+			panic("executing foreach in empty range")
+		})
+
+		rmap.ForEachInRange("order/2024-01", "order/2024-01", func(_ string, _ int) {
+			panic("executing foreach in empty range")
+		})
+	})
+
+	t.Run("count in range", func(t *testing.T) {
+		rmap := newMap()
+
+		assert.EqualValues(t, 3, rmap.CountInRange("order/2024-01", "order/2024-02"))
+		assert.EqualValues(t, 1, rmap.CountInRange("order/2024-01", "order/2024-01", radixtree.InclusiveTo()))
+		assert.EqualValues(t, 7, rmap.CountInRange("", "z"))
+		assert.EqualValues(t, 0, rmap.CountInRange("v", "z"))
+	})
+}
+
+func TestMapLongestPrefix(t *testing.T) {
+	t.Parallel()
+
+	newMap := func() *radixtree.Map[int] {
+		rmap := &radixtree.Map[int]{}
+		rmap.Add("/api", 1)
+		rmap.Add("/api/v1", 2)
+		rmap.Add("/api/v2/users", 3)
+		rmap.Add("/static", 4)
+		return rmap
+	}
+
+	t.Run("longest prefix when empty", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		_, _, found := rmap.LongestPrefix("/api")
+		assert.False(t, found)
+	})
+
+	t.Run("longest prefix of existing key is the key itself", func(t *testing.T) {
+		rmap := newMap()
+
+		key, data, found := rmap.LongestPrefix("/api/v1")
+		assert.True(t, found)
+		assert.Equal(t, "/api/v1", key)
+		assert.EqualValues(t, 2, data)
+	})
+
+	t.Run("longest prefix picks the deepest key", func(t *testing.T) {
+		rmap := newMap()
+
+		key, data, found := rmap.LongestPrefix("/api/v1/users")
+		assert.True(t, found)
+		assert.Equal(t, "/api/v1", key)
+		assert.EqualValues(t, 2, data)
+	})
+
+	t.Run("longest prefix falls back to shallower key", func(t *testing.T) {
+		rmap := newMap()
+
+		key, data, found := rmap.LongestPrefix("/api/v2/groups")
+		assert.True(t, found)
+		assert.Equal(t, "/api", key)
+		assert.EqualValues(t, 1, data)
+	})
+
+	t.Run("longest prefix not found", func(t *testing.T) {
+		rmap := newMap()
+
+		_, _, found := rmap.LongestPrefix("/ap")
+		assert.False(t, found)
+
+		_, _, found = rmap.LongestPrefix("/stat/ic")
+		assert.False(t, found)
+	})
+}
+
+func TestMapForEachPrefixOf(t *testing.T) {
+	t.Parallel()
+
+	t.Run("for each prefix of when empty", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.ForEachPrefixOf("/etc/app/conf.d", func(_ string, _ int) {
+			panic("executing foreach prefix of on empty map")
+		})
+	})
+
+	t.Run("for each prefix of visits prefixes by increasing length", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("/etc/app/conf.d/local", 4)
+		rmap.Add("/etc/app", 2)
+		rmap.Add("/etc/apparmor", 5)
+		rmap.Add("/", 1)
+		rmap.Add("/etc/app/conf.d", 3)
+
+		keys := []string{}
+		values := []int{}
+		rmap.ForEachPrefixOf("/etc/app/conf.d/local/override", func(s string, data int) {
+			keys = append(keys, s)
+			values = append(values, data)
+		})
+
+		assert.Equal(t, []string{"/", "/etc/app", "/etc/app/conf.d", "/etc/app/conf.d/local"}, keys)
+		assert.Equal(t, []int{1, 2, 3, 4}, values)
+	})
+
+	t.Run("for each prefix of stops where the path ends", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("/", 1)
+		rmap.Add("/etc/app", 2)
+		rmap.Add("/etc/app/conf.d", 3)
+
+		keys := []string{}
+		rmap.ForEachPrefixOf("/etc/apt", func(s string, _ int) {
+			keys = append(keys, s)
+		})
+
+		assert.Equal(t, []string{"/"}, keys)
+	})
+}
+
+func TestMapSeq(t *testing.T) {
+	t.Parallel()
+
+	newMap := func() *radixtree.Map[int] {
+		rmap := &radixtree.Map[int]{}
+		rmap.Add("romulus", 1)
+		rmap.Add("rubicon", 2)
+		rmap.Add("romane", 3)
+		rmap.Add("rom", 4)
+		rmap.Add("ruber", 5)
+		return rmap
+	}
+
+	t.Run("all when empty", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		for range rmap.All() {
+			panic("ranging over empty map")
+		}
+	})
+
+	t.Run("all yields keys and values in ascending order", func(t *testing.T) {
+		rmap := newMap()
+
+		keys := []string{}
+		values := []int{}
+		for key, data := range rmap.All() {
+			keys = append(keys, key)
+			values = append(values, data)
+		}
+
+		assert.Equal(t, []string{"rom", "romane", "romulus", "ruber", "rubicon"}, keys)
+		assert.Equal(t, []int{4, 3, 1, 5, 2}, values)
+	})
+
+	t.Run("keys and values", func(t *testing.T) {
+		rmap := newMap()
+
+		assert.Equal(t, []string{"rom", "romane", "romulus", "ruber", "rubicon"}, slices.Collect(rmap.Keys()))
+		assert.Equal(t, []int{4, 3, 1, 5, 2}, slices.Collect(rmap.Values()))
+	})
+
+	t.Run("with prefix", func(t *testing.T) {
+		rmap := newMap()
+
+		keys := []string{}
+		for key := range rmap.WithPrefix("rom") {
+			keys = append(keys, key)
+		}
+
+		assert.Equal(t, []string{"rom", "romane", "romulus"}, keys)
+	})
+
+	t.Run("range", func(t *testing.T) {
+		rmap := newMap()
+
+		keys := []string{}
+		for key := range rmap.Range("romane", "ruber", radixtree.InclusiveTo()) {
+			keys = append(keys, key)
+		}
+
+		assert.Equal(t, []string{"romane", "romulus", "ruber"}, keys)
+	})
+
+	t.Run("backward", func(t *testing.T) {
+		rmap := newMap()
+
+		keys := []string{}
+		for key := range rmap.Backward() {
+			keys = append(keys, key)
+		}
+
+		assert.Equal(t, []string{"rubicon", "ruber", "romulus", "romane", "rom"}, keys)
+	})
+
+	t.Run("break stops the iteration", func(t *testing.T) {
+		rmap := newMap()
+
+		keys := []string{}
+		for key := range rmap.All() {
+			keys = append(keys, key)
+			if len(keys) == 2 {
+				break
+			}
+		}
+		assert.Equal(t, []string{"rom", "romane"}, keys)
+
+		keys = []string{}
+		for key := range rmap.Backward() {
+			keys = append(keys, key)
+			if len(keys) == 2 {
+				break
+			}
+		}
+		assert.Equal(t, []string{"rubicon", "ruber"}, keys)
+
+		keys = []string{}
+		for key := range rmap.WithPrefix("ro") {
+			keys = append(keys, key)
+			break
+		}
+		assert.Equal(t, []string{"rom"}, keys)
+
+		keys = []string{}
+		for key := range rmap.Range("romane", "s") {
+			keys = append(keys, key)
+			break
+		}
+		assert.Equal(t, []string{"romane"}, keys)
+	})
+}
+
+func TestMapRemovePrefix(t *testing.T) {
+	t.Parallel()
+
+	newMap := func() *radixtree.Map[int] {
+		rmap := &radixtree.Map[int]{}
+		rmap.Add("tenant/a/x", 1)
+		rmap.Add("tenant/a/y", 2)
+		rmap.Add("tenant/a", 3)
+		rmap.Add("tenant/ab", 4)
+		rmap.Add("tenant/b/x", 5)
+		return rmap
+	}
+
+	t.Run("remove prefix when empty", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		assert.EqualValues(t, 0, rmap.RemovePrefix("tenant/"))
+		assert.EqualValues(t, 0, rmap.Size())
+	})
+
+	t.Run("remove prefix not found", func(t *testing.T) {
+		rmap := newMap()
+
+		assert.EqualValues(t, 0, rmap.RemovePrefix("tenant/c"))
+		assert.EqualValues(t, 5, rmap.Size())
+	})
+
+	t.Run("remove prefix removes every key starting with it", func(t *testing.T) {
+		rmap := newMap()
+
+		assert.EqualValues(t, 2, rmap.RemovePrefix("tenant/a/"))
+		assert.EqualValues(t, 3, rmap.Size())
+
+		keys := []string{}
+		rmap.ForEach(func(s string, _ int) {
+			keys = append(keys, s)
+		})
+		assert.Equal(t, []string{"tenant/a", "tenant/ab", "tenant/b/x"}, keys)
+
+		_, exists := rmap.Get("tenant/a/x")
+		assert.False(t, exists)
+
+		data, exists := rmap.Get("tenant/a")
+		assert.True(t, exists)
+		assert.EqualValues(t, 3, data)
+	})
+
+	t.Run("remove prefix ending inside a node", func(t *testing.T) {
+		rmap := newMap()
+
+		assert.EqualValues(t, 4, rmap.RemovePrefix("tenant/a"))
+		assert.EqualValues(t, 1, rmap.Size())
+
+		data, exists := rmap.Get("tenant/b/x")
+		assert.True(t, exists)
+		assert.EqualValues(t, 5, data)
+	})
+
+	t.Run("remove everything", func(t *testing.T) {
+		rmap := newMap()
+
+		assert.EqualValues(t, 5, rmap.RemovePrefix(""))
+		assert.EqualValues(t, 0, rmap.Size())
+
+		rmap.ForEach(func(_ string, _ int) {
+			panic("executing foreach after removing everything")
+		})
+	})
+
+	t.Run("map is usable after remove prefix", func(t *testing.T) {
+		rmap := newMap()
+
+		rmap.RemovePrefix("tenant/b")
+		rmap.Add("tenant/abc", 6)
+		rmap.Remove("tenant/a")
+
+		keys := []string{}
+		rmap.ForEach(func(s string, _ int) {
+			keys = append(keys, s)
+		})
+		assert.Equal(t, []string{"tenant/a/x", "tenant/a/y", "tenant/ab", "tenant/abc"}, keys)
+		assert.EqualValues(t, 4, rmap.Size())
+	})
+}
+
+func TestMapCountWithPrefix(t *testing.T) {
+	t.Parallel()
+
+	t.Run("count with prefix when empty", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		assert.EqualValues(t, 0, rmap.CountWithPrefix(""))
+		assert.EqualValues(t, 0, rmap.CountWithPrefix("a"))
+	})
+
+	t.Run("count with prefix", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("hearing", 1)
+		rmap.Add("hear", 2)
+		rmap.Add("heartless", 3)
+		rmap.Add("heap", 4)
+		rmap.Add("he", 5)
+
+		assert.EqualValues(t, 5, rmap.CountWithPrefix(""))
+		assert.EqualValues(t, 5, rmap.CountWithPrefix("he"))
+		assert.EqualValues(t, 4, rmap.CountWithPrefix("hea"))
+		assert.EqualValues(t, 3, rmap.CountWithPrefix("hear"))
+		assert.EqualValues(t, 1, rmap.CountWithPrefix("heart"))
+		assert.EqualValues(t, 0, rmap.CountWithPrefix("heat"))
+		assert.EqualValues(t, 0, rmap.CountWithPrefix("hearings"))
+	})
+
+	t.Run("count with prefix after overwriting and removing", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("hearing", 1)
+		rmap.Add("hear", 2)
+		rmap.Add("heartless", 3)
+		rmap.Add("hear", 4)
+		rmap.Remove("hearing")
+		rmap.Remove("hearings")
+
+		assert.EqualValues(t, 2, rmap.CountWithPrefix("hear"))
+		assert.EqualValues(t, 1, rmap.CountWithPrefix("heart"))
+
+		rmap.RemovePrefix("heart")
+
+		assert.EqualValues(t, 1, rmap.CountWithPrefix("hear"))
+		assert.EqualValues(t, 1, rmap.CountWithPrefix(""))
+	})
+}
+
+func TestMapRankAndSelect(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rank and select when empty", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		assert.EqualValues(t, 0, rmap.Rank("abc"))
+
+		_, _, found := rmap.Select(0)
+		assert.False(t, found)
+	})
+
+	t.Run("select is the inverse of rank", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("page/10", 10)
+		rmap.Add("page/1", 1)
+		rmap.Add("page/2", 2)
+		rmap.Add("page/20", 20)
+		rmap.Add("page/3", 3)
+
+		for _, key := range []string{"page/1", "page/10", "page/2", "page/20", "page/3"} {
+			index := rmap.Rank(key)
+
+			selected, _, found := rmap.Select(index)
+			assert.True(t, found)
+			assert.Equal(t, key, selected)
+		}
+
+		key, data, found := rmap.Select(3)
+		assert.True(t, found)
+		assert.Equal(t, "page/20", key)
+		assert.EqualValues(t, 20, data)
+	})
+
+	t.Run("rank of missing key", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("page/1", 1)
+		rmap.Add("page/10", 10)
+		rmap.Add("page/2", 2)
+
+		assert.EqualValues(t, 2, rmap.Rank("page/11"))
+		assert.EqualValues(t, 0, rmap.Rank("page/"))
+		assert.EqualValues(t, 3, rmap.Rank("page/3"))
+	})
+}
+
+func TestMapReadModifyWrite(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get or add when missing", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		rmap.Add("counter/a", 1)
+
+		actual, loaded := rmap.GetOrAdd("counter", 10)
+
+		assert.False(t, loaded)
+		assert.EqualValues(t, 10, actual)
+		assert.EqualValues(t, 2, rmap.Size())
+
+		data, exists := rmap.Get("counter")
+		assert.True(t, exists)
+		assert.EqualValues(t, 10, data)
+	})
+
+	t.Run("get or add when present", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		rmap.Add("counter", 1)
+
+		actual, loaded := rmap.GetOrAdd("counter", 10)
+
+		assert.True(t, loaded)
+		assert.EqualValues(t, 1, actual)
+		assert.EqualValues(t, 1, rmap.Size())
+	})
+
+	t.Run("update increments counter", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		increment := func(old int, _ bool) (int, bool) {
+			return old + 1, true
+		}
+
+		rmap.Update("hits", increment)
+		rmap.Update("hits", increment)
+		data, kept := rmap.Update("hits", increment)
+
+		assert.True(t, kept)
+		assert.EqualValues(t, 3, data)
+		assert.EqualValues(t, 1, rmap.Size())
+	})
+
+	t.Run("update removes when not kept", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		rmap.Add("hits", 1)
+		rmap.Add("hitsx", 2)
+		rmap.Add("hitsy", 3)
+		decrement := func(old int, exists bool) (int, bool) {
+			return old - 1, exists && old > 1
+		}
+
+		_, kept := rmap.Update("hits", decrement)
+		assert.False(t, kept)
+
+		_, exists := rmap.Get("hits")
+		assert.False(t, exists)
+		assert.EqualValues(t, 2, rmap.Size())
+
+		_, kept = rmap.Update("missing", decrement)
+		assert.False(t, kept)
+		assert.EqualValues(t, 2, rmap.Size())
+		assert.EqualValues(t, 0, rmap.CountWithPrefix("missing"))
+	})
+
+	t.Run("update receives whether the key exists", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		rmap.Add("butterfly", 1)
+
+		data, kept := rmap.Update("butter", func(old int, exists bool) (int, bool) {
+			assert.False(t, exists)
+			assert.EqualValues(t, 0, old)
+			return 7, true
+		})
+
+		assert.True(t, kept)
+		assert.EqualValues(t, 7, data)
+		assert.EqualValues(t, 2, rmap.Size())
+		assert.Equal(t, []string{"butter", "butterfly"}, slices.Collect(rmap.Keys()))
+	})
+
+	t.Run("upsert", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		double := func(old int, exists bool) int {
+			if !exists {
+				return 1
+			}
+			return old * 2
+		}
+
+		assert.EqualValues(t, 1, rmap.Upsert("power", double))
+		assert.EqualValues(t, 2, rmap.Upsert("power", double))
+		assert.EqualValues(t, 4, rmap.Upsert("power", double))
+		assert.EqualValues(t, 1, rmap.Size())
+	})
+
+	t.Run("compare and swap", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		rmap.Add("version", 1)
+
+		assert.False(t, rmap.CompareAndSwap("version", 2, 3))
+		assert.True(t, rmap.CompareAndSwap("version", 1, 2))
+
+		data, _ := rmap.Get("version")
+		assert.EqualValues(t, 2, data)
+
+		assert.False(t, rmap.CompareAndSwap("versions", 0, 1))
+		_, exists := rmap.Get("versions")
+		assert.False(t, exists)
+		assert.EqualValues(t, 1, rmap.Size())
+	})
+
+	t.Run("swap", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		previous, loaded := rmap.Swap("slot", 1)
+		assert.False(t, loaded)
+		assert.EqualValues(t, 0, previous)
+
+		previous, loaded = rmap.Swap("slot", 2)
+		assert.True(t, loaded)
+		assert.EqualValues(t, 1, previous)
+		assert.EqualValues(t, 1, rmap.Size())
+	})
+
+	t.Run("load and delete", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		rmap.Add("worker", 57)
+		rmap.Add("workaholic", 79)
+
+		data, loaded := rmap.LoadAndDelete("worker")
+		assert.True(t, loaded)
+		assert.EqualValues(t, 57, data)
+		assert.EqualValues(t, 1, rmap.Size())
+
+		_, loaded = rmap.LoadAndDelete("worker")
+		assert.False(t, loaded)
+
+		_, loaded = rmap.LoadAndDelete("work")
+		assert.False(t, loaded)
+		assert.EqualValues(t, 1, rmap.Size())
+	})
+}
+
+func TestMapEmptyKey(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty key can be added, got and removed", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("", 1)
+
+		data, exists := rmap.Get("")
+		assert.True(t, exists)
+		assert.EqualValues(t, 1, data)
+		assert.EqualValues(t, 1, rmap.Size())
+
+		rmap.Remove("")
+
+		_, exists = rmap.Get("")
+		assert.False(t, exists)
+		assert.EqualValues(t, 0, rmap.Size())
+	})
+
+	t.Run("empty key alongside keys without common prefix", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("abc", 1)
+		rmap.Add("xyz", 2)
+		rmap.Add("", 3)
+
+		data, exists := rmap.Get("")
+		assert.True(t, exists)
+		assert.EqualValues(t, 3, data)
+
+		keys := []string{}
+		rmap.ForEach(func(s string, _ int) {
+			keys = append(keys, s)
+		})
+		assert.Equal(t, []string{"", "abc", "xyz"}, keys)
+
+		keys = []string{}
+		rmap.ForEachWithPrefix("", func(s string, _ int) {
+			keys = append(keys, s)
+		})
+		assert.Equal(t, []string{"", "abc", "xyz"}, keys)
+
+		rmap.Remove("")
+
+		_, exists = rmap.Get("")
+		assert.False(t, exists)
+
+		data, exists = rmap.Get("abc")
+		assert.True(t, exists)
+		assert.EqualValues(t, 1, data)
+	})
+
+	t.Run("empty key is kept when removing the other keys", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("abc", 1)
+		rmap.Add("", 2)
+		rmap.Add("abd", 3)
+		rmap.Add("x", 4)
+
+		rmap.Remove("abc")
+		rmap.Remove("abd")
+		rmap.Remove("x")
+
+		data, exists := rmap.Get("")
+		assert.True(t, exists)
+		assert.EqualValues(t, 2, data)
+		assert.EqualValues(t, 1, rmap.Size())
+
+		keys := []string{}
+		rmap.ForEach(func(s string, _ int) {
+			keys = append(keys, s)
+		})
+		assert.Equal(t, []string{""}, keys)
+	})
+
+	t.Run("empty key in ordered queries", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("", 0)
+		rmap.Add("a", 1)
+		rmap.Add("b", 2)
+
+		key, _, found := rmap.Min()
+		assert.True(t, found)
+		assert.Equal(t, "", key)
+
+		key, _, found = rmap.Floor("0")
+		assert.True(t, found)
+		assert.Equal(t, "", key)
+
+		_, _, found = rmap.Predecessor("")
+		assert.False(t, found)
+
+		key, _, found = rmap.Successor("")
+		assert.True(t, found)
+		assert.Equal(t, "a", key)
+
+		key, data, found := rmap.LongestPrefix("zzz")
+		assert.True(t, found)
+		assert.Equal(t, "", key)
+		assert.EqualValues(t, 0, data)
+
+		assert.EqualValues(t, 0, rmap.Rank(""))
+		assert.EqualValues(t, 1, rmap.Rank("a"))
+		assert.EqualValues(t, 3, rmap.CountWithPrefix(""))
+		assert.EqualValues(t, 2, rmap.CountInRange("", "b"))
+		assert.Equal(t, []string{"", "a", "b"}, slices.Collect(rmap.Keys()))
+
+		keys := []string{}
+		for key := range rmap.Backward() {
+			keys = append(keys, key)
+		}
+		assert.Equal(t, []string{"b", "a", ""}, keys)
+
+		it := rmap.Iterator()
+		assert.True(t, it.Seek(""))
+		assert.Equal(t, "", it.Key())
+		assert.True(t, it.Last())
+		assert.True(t, it.Prev())
+		assert.True(t, it.Prev())
+		assert.Equal(t, "", it.Key())
+		assert.False(t, it.Prev())
+	})
+
+	t.Run("remove empty prefix removes the empty key too", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("", 0)
+		rmap.Add("a", 1)
+
+		assert.EqualValues(t, 2, rmap.RemovePrefix(""))
+		assert.EqualValues(t, 0, rmap.Size())
+	})
+}
+
+func TestMapSnapshot(t *testing.T) {
+	t.Parallel()
+
+	t.Run("snapshot is not affected by later changes", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("romane", 1)
+		rmap.Add("romanus", 2)
+		rmap.Add("romulus", 3)
+
+		snapshot := rmap.Snapshot()
+
+		rmap.Put("romane", 10)
+		rmap.Remove("romanus")
+		rmap.Add("roman", 4)
+		rmap.RemovePrefix("rom")
+		rmap.Add("rubens", 5)
+
+		assert.Equal(t, map[string]int{"romane": 1, "romanus": 2, "romulus": 3}, maps.Collect(snapshot.All()))
+		assert.EqualValues(t, 3, snapshot.Size())
+		assert.Equal(t, map[string]int{"rubens": 5}, maps.Collect(rmap.All()))
+		assert.EqualValues(t, 1, rmap.Size())
+	})
+
+	t.Run("map is not affected by changes to the snapshot", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Add("romane", 1)
+		rmap.Add("romanus", 2)
+
+		snapshot := rmap.Snapshot().With("romane", 10).Without("romanus").With("roman", 3)
+
+		assert.Equal(t, map[string]int{"romane": 1, "romanus": 2}, maps.Collect(rmap.All()))
+		assert.Equal(t, map[string]int{"romane": 10, "roman": 3}, maps.Collect(snapshot.All()))
+	})
+
+	t.Run("successive snapshots", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+		snapshots := []radixtree.ImmutableMap[int]{}
+
+		for i := 0; i < 50; i++ {
+			rmap.Put(strconv.Itoa(i%7), i)
+			if i%3 == 0 {
+				rmap.Remove(strconv.Itoa(i % 5))
+			}
+			snapshots = append(snapshots, rmap.Snapshot())
+		}
+
+		model := map[string]int{}
+		for i, snapshot := range snapshots {
+			model[strconv.Itoa(i%7)] = i
+			if i%3 == 0 {
+				delete(model, strconv.Itoa(i%5))
+			}
+			assert.Equal(t, model, maps.Collect(snapshot.All()))
+		}
+	})
+}
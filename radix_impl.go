@@ -1,34 +1,95 @@
 package radixtree
 
-import "bytes"
+import (
+	"bytes"
+	"slices"
+	"sort"
+	"sync/atomic"
+)
+
+type radixNode[V any] struct {
+	// children are kept sorted by their first byte, so that
+	// traversals visit the words in lexicographical order
+	children []*radixNode[V]
+	// only the root can have an empty part, which happens when it holds the
+	// empty word or when the words below it have no common prefix, so every
+	// other node can be found among its siblings by its first byte
+	part  string
+	final bool
+	data  V
+	// number of words in the subtree, including the node itself
+	count int64
+	// generation of the writer that owns the node, which is the only one
+	// allowed to change it in place, while the others must copy it first
+	gen uint64
+}
+
+func newRadixNode[V any](part string, final bool, data V, gen uint64) *radixNode[V] {
+	node := &radixNode[V]{
+		part:  part,
+		final: final,
+		data:  data,
+		gen:   gen,
+	}
+	if final {
+		node.count = 1
+	}
+	return node
+}
+
+var lastGeneration atomic.Uint64
 
-type radixNode struct {
-	children map[byte]*radixNode
-	part     string
-	final    bool
-	data     interface{}
+// newGeneration returns a generation which no node has yet, so writers using
+// it copy every node they change, sharing the rest with the previous trees
+func newGeneration() uint64 {
+	return lastGeneration.Add(1)
 }
 
-func newRadixNode(part string, final bool, optdata ...interface{}) *radixNode {
-	data := interface{}(nil)
-	if len(optdata) > 0 {
-		data = optdata[0]
+// own returns a node that the writer of generation gen can change in place,
+// which is either the node itself or a copy of it
+func own[V any](node *radixNode[V], gen uint64) *radixNode[V] {
+	if node.gen == gen {
+		return node
 	}
 
-	return &radixNode{
-		part:     part,
-		final:    final,
-		children: make(map[byte]*radixNode),
-		data:     data,
+	clone := *node
+	clone.children = slices.Clone(node.children)
+	clone.gen = gen
+	return &clone
+}
+
+// childIndex returns the position of the child starting with b, or
+// the position where such a child would be inserted if there is none
+func (root *radixNode[V]) childIndex(b byte) (int, bool) {
+	i := sort.Search(len(root.children), func(i int) bool {
+		return root.children[i].part[0] >= b
+	})
+	return i, i < len(root.children) && root.children[i].part[0] == b
+}
+
+func (root *radixNode[V]) child(b byte) (*radixNode[V], bool) {
+	i, exists := root.childIndex(b)
+	if !exists {
+		return nil, false
 	}
+	return root.children[i], true
+}
+
+func (root *radixNode[V]) addChild(child *radixNode[V]) {
+	i, _ := root.childIndex(child.part[0])
+	root.insertChildAt(i, child)
 }
 
-func (root *radixNode) addChild(child *radixNode) {
-	root.children[child.part[0]] = child
+func (root *radixNode[V]) insertChildAt(i int, child *radixNode[V]) {
+	root.children = append(root.children, nil)
+	copy(root.children[i+1:], root.children[i:])
+	root.children[i] = child
 }
 
-func (root *radixNode) removeChild(child *radixNode) {
-	delete(root.children, child.part[0])
+func (root *radixNode[V]) removeChildAt(i int) {
+	copy(root.children[i:], root.children[i+1:])
+	root.children[len(root.children)-1] = nil
+	root.children = root.children[:len(root.children)-1]
 }
 
 func min(a, b int) int {
@@ -48,140 +109,242 @@ func commonPrefixLength(a, b string) int {
 	return minlen
 }
 
-func add(root *radixNode, str string, optdata ...interface{}) *radixNode {
-	data := interface{}(nil)
-	if len(optdata) > 0 {
-		data = optdata[0]
-	}
+type modification int
+
+const (
+	keepWord modification = iota
+	setWord
+	deleteWord
+)
+
+// modify descends once to str, letting decide choose what happens to it
+// given its current value, and returns the new root and the change in the
+// number of words, which is -1, 0 or 1. The nodes which are not owned by
+// generation gen are copied before being changed, so they stay untouched.
+func modify[V any](root *radixNode[V], str string, gen uint64, decide func(old V, exists bool) (V, modification)) (*radixNode[V], int64) {
+	var zero V
 
 	if root == nil {
-		return newRadixNode(str, true, data)
+		data, op := decide(zero, false)
+		if op != setWord {
+			return nil, 0
+		}
+		return newRadixNode(str, true, data, gen), 1
 	}
 
 	lenPrefix := commonPrefixLength(root.part, str)
 
 	matchExactly := lenPrefix == len(root.part) && lenPrefix == len(str)
 	if matchExactly {
-		root.data = data
-		root.final = true
-		return root
+		data, op := decide(root.data, root.final)
+		switch op {
+		case setWord:
+			var added int64
+			if !root.final {
+				added = 1
+			}
+			root = own(root, gen)
+			root.data = data
+			root.final = true
+			root.count += added
+			return root, added
+		case deleteWord:
+			return removeFinal(root, gen)
+		}
+		return root, 0
 	}
 
 	rootIsPrefixOfString := lenPrefix == len(root.part)
 	if rootIsPrefixOfString {
 		endStr := str[lenPrefix:]
 
-		var newChild *radixNode
+		// the child is replaced by its position, since splitting it
+		// changes its part and it could not be searched for again
+		i, exists := root.childIndex(endStr[0])
+		if !exists {
+			data, op := decide(zero, false)
+			if op != setWord {
+				return root, 0
+			}
+			root = own(root, gen)
+			root.insertChildAt(i, newRadixNode(endStr, true, data, gen))
+			root.count++
+			return root, 1
+		}
 
-		candidateChild, exists := root.children[endStr[0]]
-		if exists {
-			newChild = add(candidateChild, endStr, data)
+		child, delta := modify(root.children[i], endStr, gen, decide)
+
+		childChanged := child != root.children[i] || delta != 0
+		if !childChanged {
+			return root, 0
+		}
+
+		root = own(root, gen)
+		root.count += delta
+
+		shouldRemoveChild := child == nil
+		if shouldRemoveChild {
+			root.removeChildAt(i)
+			if !root.final && len(root.children) == 1 {
+				mergeWithSingleChild(root, gen)
+			}
 		} else {
-			newChild = newRadixNode(endStr, true, data)
+			root.children[i] = child
 		}
 
-		root.addChild(newChild)
-		return root
+		return root, delta
+	}
+
+	// str is not in the tree, so there is nothing to do unless it must be set
+	data, op := decide(zero, false)
+	if op != setWord {
+		return root, 0
 	}
 
 	// if we got here, then the common prefix must be split
 	// into a separate node, which will be the new root
 
-	var newRoot *radixNode
+	var newRoot *radixNode[V]
 	newStringIsPrefixOfRoot := lenPrefix == len(str)
 	if newStringIsPrefixOfRoot {
 		prefix := str
-		newRoot = newRadixNode(prefix, true, data)
+		newRoot = newRadixNode(prefix, true, data, gen)
 	} else {
 		prefix := root.part[:lenPrefix]
-		newRoot = newRadixNode(prefix, false)
+		newRoot = newRadixNode(prefix, false, zero, gen)
 
 		// newRoot will have two children, one with the
 		// final part of the old root, and the other with
 		// the final part of the new string
 		endStr := str[lenPrefix:]
-		newChild := newRadixNode(endStr, true, data)
+		newChild := newRadixNode(endStr, true, data, gen)
 		newRoot.addChild(newChild)
 	}
 
 	// reuse root to avoid setting up the children again
-	oldRoot := root
+	oldRoot := own(root, gen)
 	oldRoot.part = root.part[lenPrefix:]
 
 	newRoot.addChild(oldRoot)
-	return newRoot
+	newRoot.count = oldRoot.count + 1
+	return newRoot, 1
 }
 
-func remove(root *radixNode, str string) (*radixNode, bool) {
-	if root == nil {
-		return nil, false
+// removeFinal removes the word ending at root, returning the new root
+// and the change in the number of words
+func removeFinal[V any](root *radixNode[V], gen uint64) (*radixNode[V], int64) {
+	wordIsInTree := root.final
+	if !wordIsInTree {
+		return root, 0
 	}
 
-	lenPrefix := commonPrefixLength(root.part, str)
+	shouldRemoveRoot := len(root.children) == 0
+	if shouldRemoveRoot {
+		return nil, -1
+	}
 
-	matchExactly := lenPrefix == len(root.part) && lenPrefix == len(str)
-	if matchExactly {
-		wordIsInTree := root.final
-		if !wordIsInTree {
-			return root, false
-		}
+	root = own(root, gen)
 
-		shouldRemoveRoot := len(root.children) == 0
-		if shouldRemoveRoot {
-			return nil, true
-		}
+	shouldMergeRootWithChild := len(root.children) == 1
+	if shouldMergeRootWithChild {
+		mergeWithSingleChild(root, gen)
+		return root, -1
+	}
 
-		shouldMergeRootWithChild := len(root.children) == 1
-		if shouldMergeRootWithChild {
-			mergeWithSingleChild(root)
-			return root, true
-		}
+	// if we got here, root has children, so we can just unset the final flag
+
+	var zero V
+	root.final = false
+	root.data = zero
+	root.count--
+	return root, -1
+}
+
+// add returns the new root, the value previously associated with str and
+// whether str was not in the tree before, in which case there is no such value
+func add[V any](root *radixNode[V], str string, gen uint64, data V) (*radixNode[V], V, bool) {
+	var old V
+	root, delta := modify(root, str, gen, func(current V, _ bool) (V, modification) {
+		old = current
+		return data, setWord
+	})
+	return root, old, delta > 0
+}
 
-		// if we got here, root has children, so we can just unset the final flag
+// remove returns the new root, the value associated with str
+// and whether it was removed
+func remove[V any](root *radixNode[V], str string, gen uint64) (*radixNode[V], V, bool) {
+	var old V
+	root, delta := modify(root, str, gen, func(current V, _ bool) (V, modification) {
+		old = current
+		return current, deleteWord
+	})
+	return root, old, delta < 0
+}
 
-		root.final = false
-		root.data = nil
-		return root, true
+// removePrefix detaches the subtree holding every word starting with prefix,
+// returning the new root and the number of words removed
+func removePrefix[V any](root *radixNode[V], prefix string, gen uint64) (*radixNode[V], int64) {
+	if root == nil {
+		return nil, 0
 	}
 
-	rootIsPrefixOfString := lenPrefix == len(root.part)
-	if rootIsPrefixOfString {
-		endStr := str[lenPrefix:]
+	lenPrefix := commonPrefixLength(root.part, prefix)
+
+	prefixIsPrefixOrEqualToRoot := lenPrefix == len(prefix)
+	if prefixIsPrefixOrEqualToRoot {
+		return nil, root.count
+	}
 
-		childCandidate, exists := root.children[endStr[0]]
+	rootIsPrefixOfPrefix := lenPrefix == len(root.part)
+	if rootIsPrefixOfPrefix {
+		endPrefix := prefix[lenPrefix:]
+
+		i, exists := root.childIndex(endPrefix[0])
 		if !exists {
-			return root, false
+			return root, 0
+		}
+
+		child, removed := removePrefix(root.children[i], endPrefix, gen)
+		if removed == 0 {
+			return root, 0
 		}
 
-		child, removed := remove(childCandidate, endStr)
+		root = own(root, gen)
+		root.count -= removed
 
 		shouldRemoveChild := child == nil
 		if shouldRemoveChild {
-			root.removeChild(childCandidate)
+			root.removeChildAt(i)
 			if !root.final && len(root.children) == 1 {
-				mergeWithSingleChild(root)
+				mergeWithSingleChild(root, gen)
 			}
+		} else {
+			root.children[i] = child
 		}
 
 		return root, removed
 	}
 
-	return root, false
+	return root, 0
 }
 
-func mergeWithSingleChild(node *radixNode) {
-	// use range for to select the only child
-	var child *radixNode
-	for _, child = range node.children {
-	}
+func mergeWithSingleChild[V any](node *radixNode[V], gen uint64) {
+	child := node.children[0]
 
 	node.part += child.part
 	node.children = child.children
+	if child.gen != gen {
+		// the children of a node owned by another writer
+		// must not be changed in place through node
+		node.children = slices.Clone(child.children)
+	}
 	node.final = child.final
 	node.data = child.data
+	node.count = child.count
 }
 
-func get(root *radixNode, str string) *radixNode {
+func get[V any](root *radixNode[V], str string) *radixNode[V] {
 	if root == nil {
 		return nil
 	}
@@ -197,7 +360,7 @@ func get(root *radixNode, str string) *radixNode {
 	if rootIsPrefixOfString {
 		endStr := str[lenPrefix:]
 
-		childCandidate, exists := root.children[endStr[0]]
+		childCandidate, exists := root.child(endStr[0])
 		if !exists {
 			return nil
 		}
@@ -208,13 +371,90 @@ func get(root *radixNode, str string) *radixNode {
 	return nil
 }
 
-func getWithPrefix(root *radixNode, pattern string) (*radixNode, *bytes.Buffer) {
+// longestPrefix finds the deepest final node in the path followed by get,
+// returning it with the length of its word, which is a prefix of str
+func longestPrefix[V any](root *radixNode[V], str string, lenBefore int) (*radixNode[V], int) {
+	if root == nil {
+		return nil, 0
+	}
+
+	lenPrefix := commonPrefixLength(root.part, str)
+
+	rootIsPrefixOfString := lenPrefix == len(root.part)
+	if !rootIsPrefixOfString {
+		return nil, 0
+	}
+
+	lenWord := lenBefore + lenPrefix
+
+	endStr := str[lenPrefix:]
+	if len(endStr) > 0 {
+		childCandidate, exists := root.child(endStr[0])
+		if exists {
+			node, lenChildWord := longestPrefix(childCandidate, endStr, lenWord)
+			if node != nil {
+				return node, lenChildWord
+			}
+		}
+	}
+
+	if root.final {
+		return root, lenWord
+	}
+
+	return nil, 0
+}
+
+func traversePrefixesOf[V any](root *radixNode[V], str string, action func(string, V)) {
+	traversePrefixesOfRecursive(root, str, 0, action)
+}
+
+// traversePrefixesOfRecursive follows the same path as get, visiting the
+// final nodes on the way, whose words are all prefixes of str
+func traversePrefixesOfRecursive[V any](root *radixNode[V], str string, lenBefore int, action func(string, V)) {
+	if root == nil {
+		return
+	}
+
+	lenPrefix := commonPrefixLength(root.part, str[lenBefore:])
+
+	rootIsPrefixOfString := lenPrefix == len(root.part)
+	if !rootIsPrefixOfString {
+		return
+	}
+
+	lenWord := lenBefore + lenPrefix
+	if root.final {
+		action(str[:lenWord], root.data)
+	}
+
+	if lenWord == len(str) {
+		return
+	}
+
+	childCandidate, exists := root.child(str[lenWord])
+	if !exists {
+		return
+	}
+
+	traversePrefixesOfRecursive(childCandidate, str, lenWord, action)
+}
+
+func getWithPrefix[V any](root *radixNode[V], pattern string) (*radixNode[V], *bytes.Buffer) {
 	buffer := &bytes.Buffer{}
 	node := getWithPrefixRecursive(root, pattern, buffer)
 	return node, buffer
 }
 
-func getWithPrefixRecursive(root *radixNode, pattern string, buffer *bytes.Buffer) *radixNode {
+func countWithPrefix[V any](root *radixNode[V], prefix string) int64 {
+	node, _ := getWithPrefix(root, prefix)
+	if node == nil {
+		return 0
+	}
+	return node.count
+}
+
+func getWithPrefixRecursive[V any](root *radixNode[V], pattern string, buffer *bytes.Buffer) *radixNode[V] {
 	if root == nil {
 		return nil
 	}
@@ -230,7 +470,7 @@ func getWithPrefixRecursive(root *radixNode, pattern string, buffer *bytes.Buffe
 	if rootIsPrefixOfPattern {
 		endPattern := pattern[lenPrefix:]
 
-		childCandidate, exists := root.children[endPattern[0]]
+		childCandidate, exists := root.child(endPattern[0])
 		if !exists {
 			return nil
 		}
@@ -242,25 +482,283 @@ func getWithPrefixRecursive(root *radixNode, pattern string, buffer *bytes.Buffe
 	return nil
 }
 
-func traverse(root *radixNode, action func(string, interface{})) {
+// continuing adapts an action to the traversals, which stop as soon as
+// their action returns false
+func continuing[V any](action func(string, V)) func(string, V) bool {
+	return func(str string, data V) bool {
+		action(str, data)
+		return true
+	}
+}
+
+func traverse[V any](root *radixNode[V], action func(string, V) bool) {
 	buffer := &bytes.Buffer{}
 	traverseRecursive(root, buffer, action)
 }
 
-func traverseRecursive(root *radixNode, buffer *bytes.Buffer, action func(string, interface{})) {
+// traverseRecursive returns false if the action stopped the traversal
+func traverseRecursive[V any](root *radixNode[V], buffer *bytes.Buffer, action func(string, V) bool) bool {
+	return walkRecursive(root, buffer, func(str string, data V) WalkControl {
+		if !action(str, data) {
+			return Stop
+		}
+		return Continue
+	})
+}
+
+func traverseReverse[V any](root *radixNode[V], action func(string, V) bool) {
+	buffer := &bytes.Buffer{}
+	traverseReverseRecursive(root, buffer, action)
+}
+
+// traverseReverseRecursive returns false if the action stopped the traversal
+func traverseReverseRecursive[V any](root *radixNode[V], buffer *bytes.Buffer, action func(string, V) bool) bool {
 	if root == nil {
-		return
+		return true
 	}
 
 	sizebefore := buffer.Len()
+	defer buffer.Truncate(sizebefore)
 
 	_, _ = buffer.WriteString(root.part)
+	for i := len(root.children) - 1; i >= 0; i-- {
+		if !traverseReverseRecursive(root.children[i], buffer, action) {
+			return false
+		}
+	}
+
+	// a word always comes before the words it is a prefix of,
+	// so in reverse order it is visited after its children
 	if root.final {
-		action(buffer.String(), root.data)
+		return action(buffer.String(), root.data)
 	}
+	return true
+}
 
-	for _, child := range root.children {
-		traverseRecursive(child, buffer, action)
+func nodeEntry[V any](node *radixNode[V], buffer *bytes.Buffer) (string, V, bool) {
+	if node == nil {
+		var zero V
+		return "", zero, false
 	}
+	return buffer.String(), node.data, true
+}
+
+func minimum[V any](root *radixNode[V], buffer *bytes.Buffer) *radixNode[V] {
+	if root == nil {
+		return nil
+	}
+
+	sizebefore := buffer.Len()
+
+	_, _ = buffer.WriteString(root.part)
+	if root.final {
+		return root
+	}
+
+	if len(root.children) > 0 {
+		return minimum(root.children[0], buffer)
+	}
+
+	buffer.Truncate(sizebefore)
+	return nil
+}
+
+func maximum[V any](root *radixNode[V], buffer *bytes.Buffer) *radixNode[V] {
+	if root == nil {
+		return nil
+	}
+
+	sizebefore := buffer.Len()
+
+	// the greatest word is always the deepest one in the last child,
+	// since a word always comes before the words it is a prefix of
+	_, _ = buffer.WriteString(root.part)
+	if len(root.children) > 0 {
+		return maximum(root.children[len(root.children)-1], buffer)
+	}
+
+	if root.final {
+		return root
+	}
+
+	buffer.Truncate(sizebefore)
+	return nil
+}
+
+// ceiling finds the smallest word greater than or equal to str, or
+// strictly greater than str if strict is set, writing it to buffer
+func ceiling[V any](root *radixNode[V], str string, strict bool, buffer *bytes.Buffer) *radixNode[V] {
+	if root == nil {
+		return nil
+	}
+
+	sizebefore := buffer.Len()
+
+	lenPrefix := commonPrefixLength(root.part, str)
+
+	rootIsPrefixOfString := lenPrefix == len(root.part)
+	if !rootIsPrefixOfString {
+		// either str is a prefix of the root part or they differ
+		// at some byte, which decides the side the whole subtree is on
+		subtreeIsGreater := lenPrefix == len(str) || root.part[lenPrefix] > str[lenPrefix]
+		if subtreeIsGreater {
+			return minimum(root, buffer)
+		}
+		return nil
+	}
+
+	_, _ = buffer.WriteString(root.part)
+
+	endStr := str[lenPrefix:]
+	if len(endStr) == 0 {
+		if root.final && !strict {
+			return root
+		}
+
+		// every word below root is greater than str
+		if len(root.children) > 0 {
+			return minimum(root.children[0], buffer)
+		}
+
+		buffer.Truncate(sizebefore)
+		return nil
+	}
+
+	i, exists := root.childIndex(endStr[0])
+	if exists {
+		node := ceiling(root.children[i], endStr, strict, buffer)
+		if node != nil {
+			return node
+		}
+		i++
+	}
+
+	if i < len(root.children) {
+		return minimum(root.children[i], buffer)
+	}
+
+	buffer.Truncate(sizebefore)
+	return nil
+}
+
+// floor finds the greatest word less than or equal to str, or
+// strictly less than str if strict is set, writing it to buffer
+func floor[V any](root *radixNode[V], str string, strict bool, buffer *bytes.Buffer) *radixNode[V] {
+	if root == nil {
+		return nil
+	}
+
+	sizebefore := buffer.Len()
+
+	lenPrefix := commonPrefixLength(root.part, str)
+
+	rootIsPrefixOfString := lenPrefix == len(root.part)
+	if !rootIsPrefixOfString {
+		subtreeIsSmaller := lenPrefix < len(str) && root.part[lenPrefix] < str[lenPrefix]
+		if subtreeIsSmaller {
+			return maximum(root, buffer)
+		}
+		return nil
+	}
+
+	_, _ = buffer.WriteString(root.part)
+
+	endStr := str[lenPrefix:]
+	if len(endStr) == 0 {
+		if root.final && !strict {
+			return root
+		}
+
+		// every word below root is greater than str
+		buffer.Truncate(sizebefore)
+		return nil
+	}
+
+	i, exists := root.childIndex(endStr[0])
+	if exists {
+		node := floor(root.children[i], endStr, strict, buffer)
+		if node != nil {
+			return node
+		}
+	}
+
+	if i > 0 {
+		return maximum(root.children[i-1], buffer)
+	}
+
+	// root is a proper prefix of str, so it comes right before
+	// all of its children
+	if root.final {
+		return root
+	}
+
 	buffer.Truncate(sizebefore)
+	return nil
+}
+
+// rank counts the words strictly less than str
+func rank[V any](root *radixNode[V], str string) int64 {
+	if root == nil {
+		return 0
+	}
+
+	lenPrefix := commonPrefixLength(root.part, str)
+
+	rootIsPrefixOfString := lenPrefix == len(root.part)
+	if !rootIsPrefixOfString {
+		subtreeIsSmaller := lenPrefix < len(str) && root.part[lenPrefix] < str[lenPrefix]
+		if subtreeIsSmaller {
+			return root.count
+		}
+		return 0
+	}
+
+	endStr := str[lenPrefix:]
+	if len(endStr) == 0 {
+		return 0
+	}
+
+	// root is a proper prefix of str, so it is smaller,
+	// and so are the children before the one str goes to
+	var smaller int64
+	if root.final {
+		smaller++
+	}
+
+	i, exists := root.childIndex(endStr[0])
+	for _, child := range root.children[:i] {
+		smaller += child.count
+	}
+
+	if exists {
+		smaller += rank(root.children[i], endStr)
+	}
+
+	return smaller
+}
+
+// selectAt finds the word at position index in lexicographical order,
+// starting from zero, writing it to buffer
+func selectAt[V any](root *radixNode[V], index int64, buffer *bytes.Buffer) *radixNode[V] {
+	if root == nil || index < 0 || index >= root.count {
+		return nil
+	}
+
+	_, _ = buffer.WriteString(root.part)
+	if root.final {
+		if index == 0 {
+			return root
+		}
+		index--
+	}
+
+	for _, child := range root.children {
+		if index < child.count {
+			return selectAt(child, index, buffer)
+		}
+		index -= child.count
+	}
+
+	// unreachable as long as the counts are right
+	return nil
 }
@@ -0,0 +1,164 @@
+package radixtree
+
+import "bytes"
+
+type rangeBounds struct {
+	from          string
+	to            string
+	fromInclusive bool
+	toInclusive   bool
+}
+
+// RangeOption changes which bounds of a range are included in it.
+// By default, ranges go from "from" (inclusive) to "to" (exclusive).
+type RangeOption func(*rangeBounds)
+
+func ExclusiveFrom() RangeOption {
+	return func(bounds *rangeBounds) {
+		bounds.fromInclusive = false
+	}
+}
+
+func InclusiveFrom() RangeOption {
+	return func(bounds *rangeBounds) {
+		bounds.fromInclusive = true
+	}
+}
+
+func ExclusiveTo() RangeOption {
+	return func(bounds *rangeBounds) {
+		bounds.toInclusive = false
+	}
+}
+
+func InclusiveTo() RangeOption {
+	return func(bounds *rangeBounds) {
+		bounds.toInclusive = true
+	}
+}
+
+func newRangeBounds(from, to string, opts []RangeOption) *rangeBounds {
+	bounds := &rangeBounds{
+		from:          from,
+		to:            to,
+		fromInclusive: true,
+	}
+	for _, opt := range opts {
+		opt(bounds)
+	}
+	return bounds
+}
+
+type boundRelation int
+
+const (
+	// the key and every word that has it as a prefix are below the bound
+	belowBound boundRelation = iota
+	// the key is a proper prefix of the bound
+	prefixOfBound
+	atBound
+	// the key and every word that has it as a prefix are above the bound
+	aboveBound
+)
+
+func relateToBound(key []byte, bound string) boundRelation {
+	minlen := min(len(key), len(bound))
+	switch {
+	case string(key[:minlen]) < bound[:minlen]:
+		return belowBound
+	case string(key[:minlen]) > bound[:minlen]:
+		return aboveBound
+	case len(key) < len(bound):
+		return prefixOfBound
+	case len(key) == len(bound):
+		return atBound
+	default:
+		return aboveBound
+	}
+}
+
+func traverseRange[V any](root *radixNode[V], bounds *rangeBounds, action func(string, V) bool) {
+	buffer := &bytes.Buffer{}
+	traverseRangeRecursive(root, buffer, bounds, true, true, action)
+}
+
+// traverseRangeRecursive only compares the words against the bounds that
+// may still exclude something, which are flagged by checkFrom and checkTo,
+// and returns false if the action stopped the traversal
+func traverseRangeRecursive[V any](root *radixNode[V], buffer *bytes.Buffer, bounds *rangeBounds, checkFrom, checkTo bool, action func(string, V) bool) bool {
+	if root == nil {
+		return true
+	}
+
+	sizebefore := buffer.Len()
+	defer buffer.Truncate(sizebefore)
+
+	_, _ = buffer.WriteString(root.part)
+
+	visitRoot := root.final
+	visitChildren := true
+
+	if checkFrom {
+		switch relateToBound(buffer.Bytes(), bounds.from) {
+		case belowBound:
+			return true
+		case prefixOfBound:
+			visitRoot = false
+		case atBound:
+			visitRoot = visitRoot && bounds.fromInclusive
+			checkFrom = false
+		case aboveBound:
+			checkFrom = false
+		}
+	}
+
+	if checkTo {
+		switch relateToBound(buffer.Bytes(), bounds.to) {
+		case belowBound:
+			checkTo = false
+		case prefixOfBound:
+		case atBound:
+			visitRoot = visitRoot && bounds.toInclusive
+			visitChildren = false
+		case aboveBound:
+			return true
+		}
+	}
+
+	if visitRoot && !action(buffer.String(), root.data) {
+		return false
+	}
+
+	if !visitChildren {
+		return true
+	}
+
+	for _, child := range root.children {
+		if !traverseRangeRecursive(child, buffer, bounds, checkFrom, checkTo, action) {
+			return false
+		}
+	}
+	return true
+}
+
+func countInRange[V any](root *radixNode[V], bounds *rangeBounds) int64 {
+	contains := func(str string) bool {
+		node := get(root, str)
+		return node != nil && node.final
+	}
+
+	upper := rank(root, bounds.to)
+	if bounds.toInclusive && contains(bounds.to) {
+		upper++
+	}
+
+	lower := rank(root, bounds.from)
+	if !bounds.fromInclusive && contains(bounds.from) {
+		lower++
+	}
+
+	if upper < lower {
+		return 0
+	}
+	return upper - lower
+}
@@ -1,18 +1,30 @@
 package radixtree
 
+import (
+	"bytes"
+	"io"
+	"iter"
+)
+
 type Set struct {
-	root *radixNode
+	root *radixNode[struct{}]
 	size int64
+	// generation of the nodes the set can change in place
+	gen uint64
 }
 
-func (s *Set) Add(str string) {
-	s.root = add(s.root, str)
-	s.size++
+func (s *Set) Add(str string) bool {
+	var added bool
+	s.root, _, added = add(s.root, str, s.gen, struct{}{})
+	if added {
+		s.size++
+	}
+	return added
 }
 
 func (s *Set) Remove(str string) {
 	var removed bool
-	s.root, removed = remove(s.root, str)
+	s.root, _, removed = remove(s.root, str, s.gen)
 	if removed {
 		s.size--
 	}
@@ -28,14 +40,257 @@ func (s *Set) Size() int64 {
 }
 
 func (s *Set) ForEach(action func(string)) {
-	traverse(s.root, func(s string, _ interface{}) {
+	traverse(s.root, func(s string, _ struct{}) bool {
 		action(s)
+		return true
 	})
 }
 
 func (s *Set) ForEachWithPrefix(prefix string, action func(string)) {
 	node, buffer := getWithPrefix(s.root, prefix)
-	traverseRecursive(node, buffer, func(s string, _ interface{}) {
+	traverseRecursive(node, buffer, func(s string, _ struct{}) bool {
+		action(s)
+		return true
+	})
+}
+
+func (s *Set) ForEachReverse(action func(string)) {
+	traverseReverse(s.root, func(s string, _ struct{}) bool {
+		action(s)
+		return true
+	})
+}
+
+func (s *Set) ForEachWithPrefixReverse(prefix string, action func(string)) {
+	node, buffer := getWithPrefix(s.root, prefix)
+	traverseReverseRecursive(node, buffer, func(s string, _ struct{}) bool {
+		action(s)
+		return true
+	})
+}
+
+func (s *Set) Min() (string, bool) {
+	buffer := &bytes.Buffer{}
+	str, _, found := nodeEntry(minimum(s.root, buffer), buffer)
+	return str, found
+}
+
+func (s *Set) Max() (string, bool) {
+	buffer := &bytes.Buffer{}
+	str, _, found := nodeEntry(maximum(s.root, buffer), buffer)
+	return str, found
+}
+
+func (s *Set) Floor(str string) (string, bool) {
+	buffer := &bytes.Buffer{}
+	str, _, found := nodeEntry(floor(s.root, str, false, buffer), buffer)
+	return str, found
+}
+
+func (s *Set) Ceiling(str string) (string, bool) {
+	buffer := &bytes.Buffer{}
+	str, _, found := nodeEntry(ceiling(s.root, str, false, buffer), buffer)
+	return str, found
+}
+
+func (s *Set) Predecessor(str string) (string, bool) {
+	buffer := &bytes.Buffer{}
+	str, _, found := nodeEntry(floor(s.root, str, true, buffer), buffer)
+	return str, found
+}
+
+func (s *Set) Successor(str string) (string, bool) {
+	buffer := &bytes.Buffer{}
+	str, _, found := nodeEntry(ceiling(s.root, str, true, buffer), buffer)
+	return str, found
+}
+
+func (s *Set) ForEachInRange(from, to string, action func(string), opts ...RangeOption) {
+	traverseRange(s.root, newRangeBounds(from, to, opts), func(s string, _ struct{}) bool {
+		action(s)
+		return true
+	})
+}
+
+func (s *Set) CountInRange(from, to string, opts ...RangeOption) int64 {
+	return countInRange(s.root, newRangeBounds(from, to, opts))
+}
+
+func (s *Set) LongestPrefix(str string) (string, bool) {
+	node, length := longestPrefix(s.root, str, 0)
+	if node == nil {
+		return "", false
+	}
+	return str[:length], true
+}
+
+func (s *Set) ForEachPrefixOf(str string, action func(string)) {
+	traversePrefixesOf(s.root, str, func(s string, _ struct{}) {
 		action(s)
 	})
 }
+
+// snapshot returns a frozen copy of the set in constant time. The nodes become
+// shared with it, so the set copies the ones it changes from then on.
+func (s *Set) snapshot() *Set {
+	snapshot := *s
+	s.gen = newGeneration()
+	return &snapshot
+}
+
+func (s *Set) Iterator() *SetIterator {
+	return &SetIterator{it: newIterator(s.root)}
+}
+
+func (s *Set) All() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		traverse(s.root, func(s string, _ struct{}) bool {
+			return yield(s)
+		})
+	}
+}
+
+func (s *Set) WithPrefix(prefix string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		node, buffer := getWithPrefix(s.root, prefix)
+		traverseRecursive(node, buffer, func(s string, _ struct{}) bool {
+			return yield(s)
+		})
+	}
+}
+
+func (s *Set) Range(from, to string, opts ...RangeOption) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		traverseRange(s.root, newRangeBounds(from, to, opts), func(s string, _ struct{}) bool {
+			return yield(s)
+		})
+	}
+}
+
+func (s *Set) Backward() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		traverseReverse(s.root, func(s string, _ struct{}) bool {
+			return yield(s)
+		})
+	}
+}
+
+func (s *Set) Walk(action func(string) WalkControl) {
+	walk(s.root, func(s string, _ struct{}) WalkControl {
+		return action(s)
+	})
+}
+
+func (s *Set) WalkWithPrefix(prefix string, action func(string) WalkControl) {
+	node, buffer := getWithPrefix(s.root, prefix)
+	walkRecursive(node, buffer, func(s string, _ struct{}) WalkControl {
+		return action(s)
+	})
+}
+
+func (s *Set) RemovePrefix(prefix string) int64 {
+	var removed int64
+	s.root, removed = removePrefix(s.root, prefix, s.gen)
+	s.size -= removed
+	return removed
+}
+
+func (s *Set) CountWithPrefix(prefix string) int64 {
+	return countWithPrefix(s.root, prefix)
+}
+
+func (s *Set) Rank(str string) int64 {
+	return rank(s.root, str)
+}
+
+func (s *Set) Select(index int64) (string, bool) {
+	buffer := &bytes.Buffer{}
+	str, _, found := nodeEntry(selectAt(s.root, index, buffer), buffer)
+	return str, found
+}
+
+func (s *Set) WriteTo(w io.Writer) (int64, error) {
+	return writeTree(w, s.root, nil)
+}
+
+// ReadFrom replaces the contents with the ones read from r, leaving them
+// unchanged if the encoding is invalid. It reads nothing past the end of
+// the tree, so several trees can be read from one stream.
+func (s *Set) ReadFrom(r io.Reader) (int64, error) {
+	root, n, err := readTree[struct{}](r, s.gen, nil)
+	if err == nil {
+		s.replaceRoot(root)
+	}
+	return n, err
+}
+
+func (s *Set) replaceRoot(root *radixNode[struct{}]) {
+	s.root = root
+	s.size = 0
+	if root != nil {
+		s.size = root.count
+	}
+}
+
+func (s *Set) MarshalBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	_, err := s.WriteTo(&buffer)
+	return buffer.Bytes(), err
+}
+
+func (s *Set) UnmarshalBinary(data []byte) error {
+	root, err := unmarshalTree[struct{}](data, s.gen, nil)
+	if err != nil {
+		return err
+	}
+	s.replaceRoot(root)
+	return nil
+}
+
+// MarshalJSON encodes the set as an array, with the words in
+// lexicographical order.
+func (s Set) MarshalJSON() ([]byte, error) {
+	return marshalJSONArray(s.root)
+}
+
+// UnmarshalJSON replaces the contents with the words of an array,
+// leaving them unchanged if it fails.
+func (s *Set) UnmarshalJSON(data []byte) error {
+	if isJSONNull(data) {
+		return nil
+	}
+	root, err := unmarshalJSONArray(data, s.gen)
+	if err != nil {
+		return err
+	}
+	s.replaceRoot(root)
+	return nil
+}
+
+// MarshalJSONTree encodes the nodes of the tree as nested objects,
+// which is useful for debugging.
+func (s Set) MarshalJSONTree() ([]byte, error) {
+	return marshalJSONTree(s.root, false)
+}
+
+// Save writes the set in the file format described in file.go.
+func (s *Set) Save(w io.Writer) error {
+	return writeFile(w, fileKindSet, s.root, nil)
+}
+
+// Load replaces the contents with the ones of a file written by Save,
+// leaving them unchanged if the file is invalid.
+func (s *Set) Load(r io.Reader) error {
+	root, err := readFile[struct{}](r, fileKindSet, s.gen, nil)
+	if err != nil {
+		return err
+	}
+	s.replaceRoot(root)
+	return nil
+}
+
+// WriteImage writes the set in the flat layout described in image.go,
+// which OpenMappedSet searches without loading it.
+func (s *Set) WriteImage(w io.Writer) error {
+	return writeImage(w, fileKindSet, s.root, nil)
+}
@@ -1,6 +1,9 @@
 package radixtree_test
 
 import (
+	"math/rand"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/jpholanda/radixtree"
@@ -58,6 +61,18 @@ func TestSetBasic(t *testing.T) {
 		assert.EqualValues(t, 0, set.Size())
 	})
 
+	t.Run("add reports whether the word is new", func(t *testing.T) {
+		set := radixtree.Set{}
+
+		assert.True(t, set.Add("ddd"))
+		assert.False(t, set.Add("ddd"))
+		assert.True(t, set.Add("dd"))
+		assert.False(t, set.Add("dd"))
+		assert.True(t, set.Add("dde"))
+
+		assert.EqualValues(t, 3, set.Size())
+	})
+
 	t.Run("for each when empty", func(t *testing.T) {
 		set := radixtree.Set{}
 
@@ -316,3 +331,438 @@ func TestSetThorough(t *testing.T) {
 		})
 	})
 }
+
+func TestSetOrder(t *testing.T) {
+	t.Parallel()
+
+	t.Run("for each visits words in ascending order", func(t *testing.T) {
+		set := radixtree.Set{}
+
+		set.Add("zebra")
+		set.Add("abc")
+		set.Add("abcd")
+		set.Add("ab")
+		set.Add("b")
+		set.Add("\xff")
+
+		words := []string{}
+		set.ForEach(func(s string) {
+			words = append(words, s)
+		})
+
+		assert.Equal(t, []string{"ab", "abc", "abcd", "b", "zebra", "\xff"}, words)
+	})
+
+	t.Run("for each reverse visits words in descending order", func(t *testing.T) {
+		set := radixtree.Set{}
+
+		set.Add("zebra")
+		set.Add("abc")
+		set.Add("abcd")
+		set.Add("ab")
+		set.Add("b")
+		set.Add("\xff")
+
+		words := []string{}
+		set.ForEachReverse(func(s string) {
+			words = append(words, s)
+		})
+
+		assert.Equal(t, []string{"\xff", "zebra", "b", "abcd", "abc", "ab"}, words)
+	})
+
+	t.Run("for each with prefix reverse visits words in descending order", func(t *testing.T) {
+		set := radixtree.Set{}
+
+		set.Add("arm")
+		set.Add("armor")
+		set.Add("armored")
+		set.Add("armory")
+		set.Add("art")
+
+		words := []string{}
+		set.ForEachWithPrefixReverse("armor", func(s string) {
+			words = append(words, s)
+		})
+
+		assert.Equal(t, []string{"armory", "armored", "armor"}, words)
+	})
+}
+
+func TestSetNavigation(t *testing.T) {
+	t.Parallel()
+
+	words := []string{"arm", "armor", "armored", "armory", "art", "b", "bee", "beetle"}
+	probes := []string{"", "a", "ar", "arm", "armo", "armor", "armore", "armored", "armorz", "arn", "art", "b", "be", "bee", "beet", "beetle", "beetles", "c"}
+
+	newSet := func() *radixtree.Set {
+		set := &radixtree.Set{}
+		for _, word := range words {
+			set.Add(word)
+		}
+		return set
+	}
+
+	t.Run("min and max when empty", func(t *testing.T) {
+		set := radixtree.Set{}
+
+		_, found := set.Min()
+		assert.False(t, found)
+
+		_, found = set.Max()
+		assert.False(t, found)
+	})
+
+	t.Run("min and max", func(t *testing.T) {
+		set := newSet()
+
+		word, found := set.Min()
+		assert.True(t, found)
+		assert.Equal(t, "arm", word)
+
+		word, found = set.Max()
+		assert.True(t, found)
+		assert.Equal(t, "beetle", word)
+	})
+
+	t.Run("floor and predecessor agree with sorted words", func(t *testing.T) {
+		set := newSet()
+
+		for _, probe := range probes {
+			expected, expectedFound := "", false
+			for _, word := range words {
+				if word <= probe {
+					expected, expectedFound = word, true
+				}
+			}
+			word, found := set.Floor(probe)
+			assert.Equal(t, expectedFound, found, probe)
+			assert.Equal(t, expected, word, probe)
+
+			expected, expectedFound = "", false
+			for _, word := range words {
+				if word < probe {
+					expected, expectedFound = word, true
+				}
+			}
+			word, found = set.Predecessor(probe)
+			assert.Equal(t, expectedFound, found, probe)
+			assert.Equal(t, expected, word, probe)
+		}
+	})
+
+	t.Run("ceiling and successor agree with sorted words", func(t *testing.T) {
+		set := newSet()
+
+		for _, probe := range probes {
+			expected, expectedFound := "", false
+			for i := len(words) - 1; i >= 0; i-- {
+				if words[i] >= probe {
+					expected, expectedFound = words[i], true
+				}
+			}
+			word, found := set.Ceiling(probe)
+			assert.Equal(t, expectedFound, found, probe)
+			assert.Equal(t, expected, word, probe)
+
+			expected, expectedFound = "", false
+			for i := len(words) - 1; i >= 0; i-- {
+				if words[i] > probe {
+					expected, expectedFound = words[i], true
+				}
+			}
+			word, found = set.Successor(probe)
+			assert.Equal(t, expectedFound, found, probe)
+			assert.Equal(t, expected, word, probe)
+		}
+	})
+}
+
+func TestSetRange(t *testing.T) {
+	t.Parallel()
+
+	words := []string{"arm", "armor", "armored", "armory", "art", "b", "bee", "beetle"}
+	bounds := []string{"", "a", "arm", "armo", "armor", "armored", "armorz", "art", "b", "bee", "beetles", "c"}
+
+	set := radixtree.Set{}
+	for _, word := range words {
+		set.Add(word)
+	}
+
+	t.Run("for each in range agrees with sorted words", func(t *testing.T) {
+		for _, from := range bounds {
+			for _, to := range bounds {
+				expected := []string{}
+				for _, word := range words {
+					if from <= word && word < to {
+						expected = append(expected, word)
+					}
+				}
+
+				actual := []string{}
+				set.ForEachInRange(from, to, func(s string) {
+					actual = append(actual, s)
+				})
+
+				assert.Equal(t, expected, actual, "[%s, %s)", from, to)
+				assert.EqualValues(t, len(expected), set.CountInRange(from, to), "[%s, %s)", from, to)
+			}
+		}
+	})
+
+	t.Run("for each in range with exclusive from and inclusive to agrees with sorted words", func(t *testing.T) {
+		for _, from := range bounds {
+			for _, to := range bounds {
+				expected := []string{}
+				for _, word := range words {
+					if from < word && word <= to {
+						expected = append(expected, word)
+					}
+				}
+
+				actual := []string{}
+				set.ForEachInRange(from, to, func(s string) {
+					actual = append(actual, s)
+				}, radixtree.ExclusiveFrom(), radixtree.InclusiveTo())
+
+				assert.Equal(t, expected, actual, "(%s, %s]", from, to)
+				assert.EqualValues(t, len(expected), set.CountInRange(from, to, radixtree.ExclusiveFrom(), radixtree.InclusiveTo()), "(%s, %s]", from, to)
+			}
+		}
+	})
+}
+
+func TestSetLongestPrefix(t *testing.T) {
+	t.Parallel()
+
+	t.Run("longest prefix", func(t *testing.T) {
+		set := radixtree.Set{}
+
+		set.Add("trust")
+		set.Add("trustworthy")
+		set.Add("truth")
+
+		word, found := set.LongestPrefix("trustworthiness")
+		assert.True(t, found)
+		assert.Equal(t, "trust", word)
+
+		word, found = set.LongestPrefix("trustworthy!")
+		assert.True(t, found)
+		assert.Equal(t, "trustworthy", word)
+
+		_, found = set.LongestPrefix("trus")
+		assert.False(t, found)
+	})
+}
+
+func TestSetForEachPrefixOf(t *testing.T) {
+	t.Parallel()
+
+	t.Run("for each prefix of includes the word itself", func(t *testing.T) {
+		set := radixtree.Set{}
+
+		set.Add("bliss")
+		set.Add("blissful")
+		set.Add("blissfulness")
+		set.Add("blister")
+
+		words := []string{}
+		set.ForEachPrefixOf("blissful", func(s string) {
+			words = append(words, s)
+		})
+
+		assert.Equal(t, []string{"bliss", "blissful"}, words)
+	})
+}
+
+func TestSetSeq(t *testing.T) {
+	t.Parallel()
+
+	newSet := func() *radixtree.Set {
+		set := &radixtree.Set{}
+		set.Add("bliss")
+		set.Add("blissful")
+		set.Add("blissfulness")
+		set.Add("blister")
+		set.Add("cat")
+		return set
+	}
+
+	t.Run("all", func(t *testing.T) {
+		set := newSet()
+
+		assert.Equal(t, []string{"bliss", "blissful", "blissfulness", "blister", "cat"}, slices.Collect(set.All()))
+	})
+
+	t.Run("with prefix", func(t *testing.T) {
+		set := newSet()
+
+		assert.Equal(t, []string{"blissful", "blissfulness"}, slices.Collect(set.WithPrefix("blissf")))
+	})
+
+	t.Run("range", func(t *testing.T) {
+		set := newSet()
+
+		assert.Equal(t, []string{"blissful", "blissfulness", "blister"}, slices.Collect(set.Range("blissf", "cat")))
+	})
+
+	t.Run("backward", func(t *testing.T) {
+		set := newSet()
+
+		assert.Equal(t, []string{"cat", "blister", "blissfulness", "blissful", "bliss"}, slices.Collect(set.Backward()))
+	})
+
+	t.Run("break stops the iteration", func(t *testing.T) {
+		set := newSet()
+
+		words := []string{}
+		for word := range set.All() {
+			if word == "blister" {
+				break
+			}
+			words = append(words, word)
+		}
+
+		assert.Equal(t, []string{"bliss", "blissful", "blissfulness"}, words)
+	})
+}
+
+func TestSetRemovePrefix(t *testing.T) {
+	t.Parallel()
+
+	t.Run("remove prefix merges the remaining words", func(t *testing.T) {
+		set := radixtree.Set{}
+		set.Add("worker")
+		set.Add("workers")
+		set.Add("workaholic")
+
+		assert.EqualValues(t, 2, set.RemovePrefix("worke"))
+		assert.EqualValues(t, 1, set.Size())
+		assert.True(t, set.Contains("workaholic"))
+		assert.False(t, set.Contains("worker"))
+
+		set.Add("work")
+		assert.Equal(t, []string{"work", "workaholic"}, slices.Collect(set.All()))
+	})
+}
+
+func TestSetCountWithPrefix(t *testing.T) {
+	t.Parallel()
+
+	t.Run("count with prefix agrees with brute force after random changes", func(t *testing.T) {
+		random := rand.New(rand.NewSource(42))
+		randomWord := func() string {
+			word := make([]byte, 1+random.Intn(5))
+			for i := range word {
+				word[i] = "abc"[random.Intn(3)]
+			}
+			return string(word)
+		}
+
+		set := radixtree.Set{}
+		words := map[string]bool{}
+		for i := 0; i < 2000; i++ {
+			word := randomWord()
+			switch random.Intn(10) {
+			case 0:
+				prefix := word[:len(word)/2]
+				set.RemovePrefix(prefix)
+				for w := range words {
+					if strings.HasPrefix(w, prefix) {
+						delete(words, w)
+					}
+				}
+			case 1, 2, 3, 4:
+				set.Remove(word)
+				delete(words, word)
+			default:
+				set.Add(word)
+				words[word] = true
+			}
+
+			prefix := randomWord()
+			prefix = prefix[:random.Intn(len(prefix)+1)]
+			expected := 0
+			for w := range words {
+				if strings.HasPrefix(w, prefix) {
+					expected++
+				}
+			}
+			assert.EqualValues(t, expected, set.CountWithPrefix(prefix), prefix)
+		}
+	})
+}
+
+func TestSetRankAndSelect(t *testing.T) {
+	t.Parallel()
+
+	words := []string{"arm", "armor", "armored", "armory", "art", "b", "bee", "beetle"}
+	probes := []string{"", "a", "arm", "armo", "armor", "armored", "armorz", "art", "b", "bee", "beetles", "c"}
+
+	set := radixtree.Set{}
+	for _, word := range words {
+		set.Add(word)
+	}
+
+	t.Run("rank agrees with sorted words", func(t *testing.T) {
+		for _, probe := range probes {
+			expected := 0
+			for _, word := range words {
+				if word < probe {
+					expected++
+				}
+			}
+			assert.EqualValues(t, expected, set.Rank(probe), probe)
+		}
+	})
+
+	t.Run("select agrees with sorted words", func(t *testing.T) {
+		for i, expected := range words {
+			word, found := set.Select(int64(i))
+			assert.True(t, found)
+			assert.Equal(t, expected, word)
+		}
+	})
+
+	t.Run("select out of bounds", func(t *testing.T) {
+		_, found := set.Select(-1)
+		assert.False(t, found)
+
+		_, found = set.Select(int64(len(words)))
+		assert.False(t, found)
+	})
+}
+
+func TestSetEmptyWord(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty word is a regular word", func(t *testing.T) {
+		set := radixtree.Set{}
+
+		assert.False(t, set.Contains(""))
+		assert.True(t, set.Add(""))
+		assert.False(t, set.Add(""))
+		assert.True(t, set.Contains(""))
+
+		set.Add("trust")
+		set.Add("truth")
+
+		words := []string{}
+		set.ForEachWithPrefix("", func(s string) {
+			words = append(words, s)
+		})
+		assert.Equal(t, []string{"", "trust", "truth"}, words)
+
+		words = []string{}
+		set.ForEachPrefixOf("trusty", func(s string) {
+			words = append(words, s)
+		})
+		assert.Equal(t, []string{"", "trust"}, words)
+
+		set.Remove("")
+		assert.False(t, set.Contains(""))
+		assert.True(t, set.Contains("trust"))
+		assert.True(t, set.Contains("truth"))
+		assert.EqualValues(t, 2, set.Size())
+	})
+}
@@ -0,0 +1,52 @@
+package radixtree
+
+import "errors"
+
+var (
+	ErrTxnConflict = errors.New("radixtree: map changed since the transaction started")
+	ErrTxnClosed   = errors.New("radixtree: transaction already committed or aborted")
+)
+
+// Txn is a set of changes to a Map which are published all at once by Commit,
+// or discarded by Abort. It offers the whole Map API, and sees its own
+// changes, while the map doesn't see any of them until the commit. Changes
+// made after Commit or Abort are never published.
+type Txn[V any] struct {
+	Map[V]
+	target *Map[V]
+	base   *radixNode[V]
+	closed bool
+}
+
+// Txn starts a transaction on the map. It shares the nodes of the map,
+// copying only the ones it changes, so starting it takes constant time.
+func (m *Map[V]) Txn() *Txn[V] {
+	txn := &Txn[V]{Map: *m, target: m, base: m.root}
+	txn.gen = newGeneration()
+	m.gen = newGeneration()
+	return txn
+}
+
+// Commit publishes the changes of the transaction to the map, unless the map
+// was changed since the transaction started, in which case it returns
+// ErrTxnConflict and publishes nothing.
+func (txn *Txn[V]) Commit() error {
+	if txn.closed {
+		return ErrTxnClosed
+	}
+	txn.closed = true
+	if txn.target.root != txn.base {
+		return ErrTxnConflict
+	}
+	txn.target.root = txn.root
+	txn.target.size = txn.size
+	// the map and the transaction share the nodes from now on
+	txn.target.gen = newGeneration()
+	txn.gen = newGeneration()
+	return nil
+}
+
+// Abort discards the changes of the transaction.
+func (txn *Txn[V]) Abort() {
+	txn.closed = true
+}
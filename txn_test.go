@@ -0,0 +1,106 @@
+package radixtree_test
+
+import (
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxn(t *testing.T) {
+	t.Parallel()
+
+	newMap := func() *radixtree.Map[int] {
+		rmap := &radixtree.Map[int]{}
+		rmap.Add("tenant/a/x", 1)
+		rmap.Add("tenant/a/y", 2)
+		rmap.Add("tenant/b/z", 3)
+		return rmap
+	}
+
+	t.Run("sees its own changes", func(t *testing.T) {
+		rmap := newMap()
+
+		txn := rmap.Txn()
+		txn.Put("tenant/a/x", 10)
+		txn.Remove("tenant/b/z")
+		txn.Add("tenant/c/w", 4)
+
+		data, exists := txn.Get("tenant/a/x")
+		assert.True(t, exists)
+		assert.EqualValues(t, 10, data)
+		assert.EqualValues(t, 3, txn.Size())
+		assert.EqualValues(t, 0, txn.CountWithPrefix("tenant/b/"))
+
+		assert.Equal(t, map[string]int{"tenant/a/x": 1, "tenant/a/y": 2, "tenant/b/z": 3}, maps.Collect(rmap.All()))
+	})
+
+	t.Run("commit publishes all the changes", func(t *testing.T) {
+		rmap := newMap()
+
+		// rename the namespace a to d
+		txn := rmap.Txn()
+		for key, data := range rmap.WithPrefix("tenant/a/") {
+			txn.Remove(key)
+			txn.Add("tenant/d/"+strings.TrimPrefix(key, "tenant/a/"), data)
+		}
+		assert.NoError(t, txn.Commit())
+
+		assert.Equal(t, map[string]int{"tenant/d/x": 1, "tenant/d/y": 2, "tenant/b/z": 3}, maps.Collect(rmap.All()))
+		assert.EqualValues(t, 3, rmap.Size())
+
+		// later changes to the transaction are not published
+		txn.Add("tenant/e/v", 5)
+		_, exists := rmap.Get("tenant/e/v")
+		assert.False(t, exists)
+		assert.Equal(t, radixtree.ErrTxnClosed, txn.Commit())
+
+		// and later changes to the map don't affect the transaction
+		rmap.Remove("tenant/d/x")
+		_, exists = txn.Get("tenant/d/x")
+		assert.True(t, exists)
+	})
+
+	t.Run("abort discards all the changes", func(t *testing.T) {
+		rmap := newMap()
+
+		txn := rmap.Txn()
+		txn.RemovePrefix("tenant/")
+		txn.Add("other", 5)
+		txn.Abort()
+
+		assert.Equal(t, map[string]int{"tenant/a/x": 1, "tenant/a/y": 2, "tenant/b/z": 3}, maps.Collect(rmap.All()))
+		assert.EqualValues(t, 3, rmap.Size())
+		assert.Equal(t, radixtree.ErrTxnClosed, txn.Commit())
+	})
+
+	t.Run("map changed since the transaction started", func(t *testing.T) {
+		rmap := newMap()
+
+		txn := rmap.Txn()
+		txn.Add("tenant/c/w", 4)
+		rmap.Put("tenant/a/x", 10)
+
+		assert.Equal(t, radixtree.ErrTxnConflict, txn.Commit())
+		assert.Equal(t, map[string]int{"tenant/a/x": 10, "tenant/a/y": 2, "tenant/b/z": 3}, maps.Collect(rmap.All()))
+	})
+
+	t.Run("concurrent transactions", func(t *testing.T) {
+		rmap := newMap()
+
+		first := rmap.Txn()
+		second := rmap.Txn()
+		first.Add("first", 1)
+		second.Add("second", 2)
+
+		assert.NoError(t, first.Commit())
+		assert.Equal(t, radixtree.ErrTxnConflict, second.Commit())
+
+		_, exists := rmap.Get("first")
+		assert.True(t, exists)
+		_, exists = rmap.Get("second")
+		assert.False(t, exists)
+	})
+}
@@ -0,0 +1,49 @@
+package radixtree
+
+import "bytes"
+
+// WalkControl is returned by the callbacks of Walk and WalkWithPrefix to
+// decide how the traversal goes on after visiting a word.
+type WalkControl int
+
+const (
+	// Continue goes on to the next word.
+	Continue WalkControl = iota
+	// SkipChildren goes on to the next word, skipping every word which
+	// has the visited word as a prefix.
+	SkipChildren
+	// Stop ends the traversal.
+	Stop
+)
+
+func walk[V any](root *radixNode[V], action func(string, V) WalkControl) {
+	buffer := &bytes.Buffer{}
+	walkRecursive(root, buffer, action)
+}
+
+// walkRecursive returns false if the action stopped the traversal
+func walkRecursive[V any](root *radixNode[V], buffer *bytes.Buffer, action func(string, V) WalkControl) bool {
+	if root == nil {
+		return true
+	}
+
+	sizebefore := buffer.Len()
+	defer buffer.Truncate(sizebefore)
+
+	_, _ = buffer.WriteString(root.part)
+	if root.final {
+		switch action(buffer.String(), root.data) {
+		case SkipChildren:
+			return true
+		case Stop:
+			return false
+		}
+	}
+
+	for _, child := range root.children {
+		if !walkRecursive(child, buffer, action) {
+			return false
+		}
+	}
+	return true
+}
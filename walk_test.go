@@ -0,0 +1,125 @@
+package radixtree_test
+
+import (
+	"testing"
+
+	"github.com/jpholanda/radixtree"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+
+	newMap := func() *radixtree.Map[int] {
+		rmap := &radixtree.Map[int]{}
+		rmap.Add("arm", 1)
+		rmap.Add("armor", 2)
+		rmap.Add("armored", 3)
+		rmap.Add("armory", 4)
+		rmap.Add("art", 5)
+		rmap.Add("artist", 6)
+		rmap.Add("b", 7)
+		return rmap
+	}
+
+	t.Run("walk when empty", func(t *testing.T) {
+		rmap := radixtree.Map[int]{}
+
+		rmap.Walk(func(_ string, _ int) radixtree.WalkControl {
+			panic("walking empty map")
+		})
+	})
+
+	t.Run("walk continuing visits every key", func(t *testing.T) {
+		rmap := newMap()
+
+		keys := []string{}
+		values := []int{}
+		rmap.Walk(func(s string, data int) radixtree.WalkControl {
+			keys = append(keys, s)
+			values = append(values, data)
+			return radixtree.Continue
+		})
+
+		assert.Equal(t, []string{"arm", "armor", "armored", "armory", "art", "artist", "b"}, keys)
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, values)
+	})
+
+	t.Run("walk stops after the first keys", func(t *testing.T) {
+		rmap := newMap()
+
+		keys := []string{}
+		rmap.Walk(func(s string, _ int) radixtree.WalkControl {
+			keys = append(keys, s)
+			if len(keys) == 3 {
+				return radixtree.Stop
+			}
+			return radixtree.Continue
+		})
+
+		assert.Equal(t, []string{"arm", "armor", "armored"}, keys)
+	})
+
+	t.Run("walk skips children", func(t *testing.T) {
+		rmap := newMap()
+
+		keys := []string{}
+		rmap.Walk(func(s string, _ int) radixtree.WalkControl {
+			keys = append(keys, s)
+			if s == "armor" || s == "art" {
+				return radixtree.SkipChildren
+			}
+			return radixtree.Continue
+		})
+
+		assert.Equal(t, []string{"arm", "armor", "art", "b"}, keys)
+	})
+
+	t.Run("walk with prefix", func(t *testing.T) {
+		rmap := newMap()
+
+		keys := []string{}
+		rmap.WalkWithPrefix("armo", func(s string, _ int) radixtree.WalkControl {
+			keys = append(keys, s)
+			if len(keys) == 2 {
+				return radixtree.Stop
+			}
+			return radixtree.Continue
+		})
+
+		assert.Equal(t, []string{"armor", "armored"}, keys)
+	})
+
+	t.Run("walk with prefix not found", func(t *testing.T) {
+		rmap := newMap()
+
+		rmap.WalkWithPrefix("c", func(_ string, _ int) radixtree.WalkControl {
+			panic("walking with prefix not found")
+		})
+	})
+
+	t.Run("set walk", func(t *testing.T) {
+		set := radixtree.Set{}
+		set.Add("arm")
+		set.Add("armor")
+		set.Add("army")
+		set.Add("art")
+
+		words := []string{}
+		set.Walk(func(s string) radixtree.WalkControl {
+			words = append(words, s)
+			if s == "arm" {
+				return radixtree.SkipChildren
+			}
+			return radixtree.Continue
+		})
+		assert.Equal(t, []string{"arm", "art"}, words)
+
+		words = []string{}
+		set.WalkWithPrefix("arm", func(s string) radixtree.WalkControl {
+			words = append(words, s)
+			return radixtree.Stop
+		})
+		assert.Equal(t, []string{"arm"}, words)
+	})
+}